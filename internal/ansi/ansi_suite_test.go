@@ -0,0 +1,54 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ansi_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gonvenience/bunt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/ansi"
+)
+
+func TestAnsi(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ANSI Virtual Terminal Suite")
+}
+
+// screen returns the plain text content of the virtual terminal after
+// processing the given input
+func screen(columns int, rows int, input string) string {
+	vt := NewVirtualTerminal(columns, rows)
+	_, _ = vt.Write([]byte(input))
+	return text(vt.Content())
+}
+
+func text(content bunt.String) string {
+	var sb strings.Builder
+	for _, cr := range content {
+		sb.WriteRune(cr.Symbol)
+	}
+
+	return sb.String()
+}
@@ -1,352 +1,659 @@
 package ansi
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gonvenience/bunt"
 )
 
-// VirtualTerminal represents a virtual terminal buffer that properly handles
-// cursor positioning, clearing operations, and ANSI escape sequences
+// parser states of the escape sequence state machine, which follows the
+// VT500 series parser model (without the C1 control codes)
+const (
+	stateGround = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSI
+	stateCSIIgnore
+	stateOSC
+	stateString
+)
+
+// maxSequenceLength is the maximum length of the parameters and intermediate
+// characters of a control sequence, longer sequences are ignored
+const maxSequenceLength = 256
+
+// ScreenBuffer selects which screen buffer of the virtual terminal is used
+// as the content
+type ScreenBuffer int
+
+const (
+	// ActiveBuffer is the screen buffer that is shown at the end of the
+	// output, like a terminal would show it
+	ActiveBuffer ScreenBuffer = iota
+
+	// PrimaryBuffer is the normal screen including the scrollback buffer,
+	// even if a full-screen program did not leave the alternate screen
+	PrimaryBuffer
+
+	// AlternateBuffer is the screen used by full-screen programs, in case it
+	// was left or erased, its last content is used
+	AlternateBuffer
+)
+
+// VirtualTerminal is a screen-grid terminal emulator with a fixed number of
+// columns and rows, which processes output like an xterm compatible terminal
+// would: cursor movement, erasing, scroll regions, insert and delete of lines
+// and characters, saving and restoring the cursor, the alternate screen, and
+// tab stops. Lines scrolled off the top of the primary screen are kept in a
+// scrollback buffer, so that the content includes all output.
 type VirtualTerminal struct {
-	lines      [][]bunt.ColoredRune
-	cursorX    int
-	cursorY    int
-	settings   uint64
-	maxColumns int
+	columns int
+	rows    int
+
+	primary    *buffer
+	alternate  *buffer
+	active     *buffer
+	scrollback []line
+
+	show          ScreenBuffer
+	lastAlternate []line
+	noScrollback  bool
+
+	cursor      cursor
+	wrapPending bool
+	lastPrinted rune
+
+	scrollTop    int
+	scrollBottom int
+	tabStops     map[int]struct{}
+
+	autoWrap      bool
+	insertMode    bool
+	newLineMode   bool
+	cursorVisible bool
+
+	// parser state
+	state        int
+	sequence     []byte
+	intermediate byte
+	pending      []byte
 }
 
-// NewVirtualTerminal creates a new virtual terminal with the specified column limit
-func NewVirtualTerminal(maxColumns int) *VirtualTerminal {
-	if maxColumns <= 0 {
-		maxColumns = 80
+// NewVirtualTerminal creates a new virtual terminal with the given number of
+// columns and rows, with zero rows the screen grows with the content instead
+// of scrolling, which is useful for output without a known terminal height
+func NewVirtualTerminal(columns int, rows int) *VirtualTerminal {
+	if columns <= 0 {
+		columns = 80
+	}
+
+	if rows < 0 {
+		rows = 0
 	}
-	return &VirtualTerminal{
-		lines:      [][]bunt.ColoredRune{{}},
-		cursorX:    0,
-		cursorY:    0,
-		maxColumns: maxColumns,
+
+	vt := &VirtualTerminal{
+		columns: columns,
+		rows:    rows,
 	}
+
+	vt.reset()
+	return vt
+}
+
+// Show selects the screen buffer, which is used as the content
+func (vt *VirtualTerminal) Show(buffer ScreenBuffer) *VirtualTerminal {
+	vt.show = buffer
+	return vt
+}
+
+// Scrollback configures whether the lines scrolled off the top of the primary
+// screen are part of the content, which is the default
+func (vt *VirtualTerminal) Scrollback(include bool) *VirtualTerminal {
+	vt.noScrollback = !include
+	return vt
 }
 
 // Parse processes ANSI input and returns a bunt.String with proper handling
 // of cursor movements and escape sequences
 func (vt *VirtualTerminal) Parse(input io.Reader) (*bunt.String, error) {
-	data, err := io.ReadAll(input)
-	if err != nil {
+	if _, err := io.Copy(vt, input); err != nil {
 		return nil, fmt.Errorf("failed to read input: %w", err)
 	}
 
-	// First, parse with bunt to get the color information
-	parsed, err := bunt.ParseStream(bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse ANSI stream: %w", err)
+	content := vt.Content()
+	return &content, nil
+}
+
+// Write processes the output of a program, incomplete escape sequences or
+// UTF-8 characters at the end are completed by the next write
+func (vt *VirtualTerminal) Write(p []byte) (int, error) {
+	for _, b := range p {
+		vt.process(b)
 	}
 
-	// Process each rune with cursor awareness
-	i := 0
-	for i < len(*parsed) {
-		cr := (*parsed)[i]
-		
-		// Check for escape sequences
-		if cr.Symbol == '\033' {
-			// Handle CSI sequences (ESC [)
-			if i+1 < len(*parsed) && (*parsed)[i+1].Symbol == '[' {
-				consumed, handled := vt.handleCSI(parsed, i)
-				if handled {
-					i += consumed
-					continue
-				}
-			}
-			i++
-			continue
-		}
+	return len(p), nil
+}
 
-		// Handle carriage return
-		if cr.Symbol == '\r' {
-			vt.cursorX = 0
-			i++
-			continue
+// Content returns the scrollback buffer and the current screen, trailing
+// empty lines and trailing blank cells of each line are omitted. While the
+// alternate screen is active, only the alternate screen is returned like a
+// terminal would show it, unless another screen buffer is selected.
+func (vt *VirtualTerminal) Content() bunt.String {
+	var lines []line
+	switch {
+	case vt.show == AlternateBuffer:
+		lines = vt.alternate.lines
+		if blank(lines) {
+			lines = vt.lastAlternate
 		}
 
-		// Handle newline
-		if cr.Symbol == '\n' {
-			vt.cursorY++
-			vt.cursorX = 0
-			vt.ensureLineExists(vt.cursorY)
-			i++
-			continue
+	case vt.show == ActiveBuffer && vt.active == vt.alternate:
+		lines = vt.alternate.lines
+
+	case vt.noScrollback:
+		lines = vt.primary.lines
+
+	default:
+		lines = append(append([]line{}, vt.scrollback...), vt.primary.lines...)
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1].blank() {
+		lines = lines[:len(lines)-1]
+	}
+
+	var result bunt.String
+	for i, l := range lines {
+		if i > 0 {
+			result = append(result, bunt.ColoredRune{Symbol: '\n'})
 		}
 
-		// Handle backspace
-		if cr.Symbol == '\b' {
-			if vt.cursorX > 0 {
-				vt.cursorX--
+		for _, cr := range l.trimmed() {
+			// skip the second cell of wide characters
+			if cr.Symbol == 0 {
+				continue
 			}
-			i++
-			continue
-		}
 
-		// Regular character - place it at cursor position
-		vt.ensureLineExists(vt.cursorY)
-		
-		// Ensure the line has enough capacity
-		for len(vt.lines[vt.cursorY]) <= vt.cursorX {
-			vt.lines[vt.cursorY] = append(vt.lines[vt.cursorY], bunt.ColoredRune{Symbol: ' '})
-		}
-		
-		// Check for line wrap
-		if vt.cursorX >= vt.maxColumns {
-			vt.cursorY++
-			vt.cursorX = 0
-			vt.ensureLineExists(vt.cursorY)
+			result = append(result, cr)
 		}
-
-		vt.lines[vt.cursorY][vt.cursorX] = cr
-		vt.cursorX++
-		i++
 	}
 
-	// Convert back to bunt.String
-	return vt.toBuntString(), nil
+	return result
 }
 
-// handleCSI processes CSI (Control Sequence Introducer) escape sequences
-// Returns: (number of runes consumed, whether sequence was handled)
-func (vt *VirtualTerminal) handleCSI(parsed *bunt.String, start int) (int, bool) {
-	if start+2 >= len(*parsed) {
-		return 0, false
-	}
+// reset sets the terminal into its initial state, only the scrollback buffer
+// is kept
+func (vt *VirtualTerminal) reset() {
+	vt.primary = &buffer{}
+	vt.alternate = &buffer{}
+	vt.active = vt.primary
 
-	// Start after ESC[
-	i := start + 2
-	var params []int
-	var paramBuf strings.Builder
-
-	// Parse parameters
-	for i < len(*parsed) {
-		ch := (*parsed)[i].Symbol
-		
-		if ch >= '0' && ch <= '9' {
-			paramBuf.WriteRune(ch)
-			i++
-		} else if ch == ';' {
-			if paramBuf.Len() > 0 {
-				val, _ := strconv.Atoi(paramBuf.String())
-				params = append(params, val)
-				paramBuf.Reset()
-			} else {
-				params = append(params, 0)
-			}
-			i++
-		} else {
-			// Command letter found
-			if paramBuf.Len() > 0 {
-				val, _ := strconv.Atoi(paramBuf.String())
-				params = append(params, val)
-			}
-			break
-		}
+	vt.cursor = cursor{}
+	vt.wrapPending = false
+	vt.lastPrinted = 0
+
+	vt.scrollTop, vt.scrollBottom = 0, vt.rows-1
+
+	vt.tabStops = map[int]struct{}{}
+	for x := 8; x < vt.columns; x += 8 {
+		vt.tabStops[x] = struct{}{}
 	}
 
-	if i >= len(*parsed) {
-		return 0, false
+	vt.autoWrap = true
+	vt.insertMode = false
+	vt.newLineMode = true
+	vt.cursorVisible = true
+
+	vt.state = stateGround
+	vt.sequence = nil
+	vt.pending = nil
+
+	vt.primary.lines = vt.blankLines(max(vt.rows, 1))
+	vt.alternate.lines = vt.blankLines(max(vt.rows, 1))
+}
+
+// process feeds one byte into the escape sequence state machine
+func (vt *VirtualTerminal) process(b byte) {
+	// CAN and SUB cancel any sequence, ESC starts a new one (which also
+	// terminates strings like OSC)
+	switch b {
+	case 0x18, 0x1A:
+		vt.state = stateGround
+		return
+
+	case 0x1B:
+		vt.flushPending()
+		vt.state = stateEscape
+		vt.intermediate = 0
+		return
 	}
 
-	command := (*parsed)[i].Symbol
-	consumed := i - start + 1
+	switch vt.state {
+	case stateGround:
+		vt.ground(b)
 
-	// Handle different CSI commands
-	switch command {
-	case 'A': // Cursor Up
-		n := 1
-		if len(params) > 0 && params[0] > 0 {
-			n = params[0]
-		}
-		vt.cursorY -= n
-		if vt.cursorY < 0 {
-			vt.cursorY = 0
-		}
-		return consumed, true
+	case stateEscape:
+		vt.escape(b)
 
-	case 'B': // Cursor Down
-		n := 1
-		if len(params) > 0 && params[0] > 0 {
-			n = params[0]
-		}
-		vt.cursorY += n
-		vt.ensureLineExists(vt.cursorY)
-		return consumed, true
-
-	case 'C': // Cursor Forward
-		n := 1
-		if len(params) > 0 && params[0] > 0 {
-			n = params[0]
-		}
-		vt.cursorX += n
-		return consumed, true
+	case stateEscapeIntermediate:
+		vt.escapeIntermediate(b)
 
-	case 'D': // Cursor Back
-		n := 1
-		if len(params) > 0 && params[0] > 0 {
-			n = params[0]
-		}
-		vt.cursorX -= n
-		if vt.cursorX < 0 {
-			vt.cursorX = 0
-		}
-		return consumed, true
+	case stateCSI:
+		switch {
+		case b < 0x20:
+			// control characters are executed within control sequences
+			vt.control(b)
 
-	case 'G': // Cursor Horizontal Absolute
-		n := 1
-		if len(params) > 0 {
-			n = params[0]
-		}
-		vt.cursorX = n - 1
-		if vt.cursorX < 0 {
-			vt.cursorX = 0
-		}
-		return consumed, true
+		case b >= 0x40 && b <= 0x7E:
+			vt.csi(b)
+			vt.state = stateGround
 
-	case 'H', 'f': // Cursor Position
-		row, col := 1, 1
-		if len(params) > 0 {
-			row = params[0]
-		}
-		if len(params) > 1 {
-			col = params[1]
-		}
-		vt.cursorY = row - 1
-		vt.cursorX = col - 1
-		if vt.cursorY < 0 {
-			vt.cursorY = 0
-		}
-		if vt.cursorX < 0 {
-			vt.cursorX = 0
-		}
-		vt.ensureLineExists(vt.cursorY)
-		return consumed, true
+		case len(vt.sequence) >= maxSequenceLength:
+			// the rest of an overlong sequence is ignored up to its end
+			vt.sequence = vt.sequence[:0]
+			vt.state = stateCSIIgnore
 
-	case 'J': // Erase in Display
-		mode := 0
-		if len(params) > 0 {
-			mode = params[0]
-		}
-		switch mode {
-		case 0: // Clear from cursor to end of screen
-			vt.clearToEndOfScreen()
-		case 1: // Clear from cursor to beginning of screen
-			vt.clearToBeginningOfScreen()
-		case 2, 3: // Clear entire screen
-			vt.clearScreen()
+		default:
+			vt.sequence = append(vt.sequence, b)
 		}
-		return consumed, true
 
-	case 'K': // Erase in Line
-		mode := 0
-		if len(params) > 0 {
-			mode = params[0]
+	case stateCSIIgnore:
+		switch {
+		case b < 0x20:
+			vt.control(b)
+
+		case b >= 0x40 && b <= 0x7E:
+			vt.state = stateGround
 		}
-		switch mode {
-		case 0: // Clear from cursor to end of line
-			vt.clearToEndOfLine()
-		case 1: // Clear from cursor to beginning of line
-			vt.clearToBeginningOfLine()
-		case 2: // Clear entire line
-			vt.clearLine()
+
+	case stateOSC, stateString:
+		// Operating system commands (e.g. the window title) and other
+		// strings have no effect on the screen content
+		if b == 0x07 {
+			vt.state = stateGround
 		}
-		return consumed, true
+	}
+}
 
-	case 's': // Save cursor position
-		// Could implement if needed
-		return consumed, true
+// ground handles text and control characters outside of sequences
+func (vt *VirtualTerminal) ground(b byte) {
+	if b < 0x20 || b == 0x7F {
+		vt.flushPending()
+		vt.control(b)
+		return
+	}
+
+	if b < 0x80 && len(vt.pending) == 0 {
+		vt.print(rune(b))
+		return
+	}
 
-	case 'u': // Restore cursor position
-		// Could implement if needed
-		return consumed, true
+	// A new character while the previous one is incomplete means that the
+	// previous sequence is invalid
+	if len(vt.pending) > 0 && utf8.RuneStart(b) {
+		vt.flushPending()
 	}
 
-	return 0, false
+	vt.pending = append(vt.pending, b)
+	if utf8.FullRune(vt.pending) {
+		r, _ := utf8.DecodeRune(vt.pending)
+		vt.pending = vt.pending[:0]
+		vt.print(r)
+	}
 }
 
-// ensureLineExists makes sure the line at the given index exists
-func (vt *VirtualTerminal) ensureLineExists(line int) {
-	for len(vt.lines) <= line {
-		vt.lines = append(vt.lines, []bunt.ColoredRune{})
+// flushPending prints an incomplete UTF-8 sequence as a replacement character
+func (vt *VirtualTerminal) flushPending() {
+	if len(vt.pending) > 0 {
+		vt.pending = vt.pending[:0]
+		vt.print(utf8.RuneError)
 	}
 }
 
-// clearToEndOfLine clears from cursor to end of current line
-func (vt *VirtualTerminal) clearToEndOfLine() {
-	if vt.cursorY < len(vt.lines) {
-		if vt.cursorX < len(vt.lines[vt.cursorY]) {
-			vt.lines[vt.cursorY] = vt.lines[vt.cursorY][:vt.cursorX]
+// control executes a C0 control character
+func (vt *VirtualTerminal) control(b byte) {
+	switch b {
+	case '\b':
+		vt.moveTo(vt.cursor.x-1, vt.cursor.y)
+
+	case '\t':
+		vt.tab(1)
+
+	case '\n', '\v', '\f':
+		vt.lineFeed()
+		if vt.newLineMode {
+			vt.carriageReturn()
 		}
+
+	case '\r':
+		vt.carriageReturn()
+
+	case 0x0E: // SO, shift out to the G1 character set
+		vt.cursor.shift = 1
+
+	case 0x0F: // SI, shift in to the G0 character set
+		vt.cursor.shift = 0
 	}
 }
 
-// clearToBeginningOfLine clears from beginning of line to cursor
-func (vt *VirtualTerminal) clearToBeginningOfLine() {
-	if vt.cursorY < len(vt.lines) {
-		for i := 0; i <= vt.cursorX && i < len(vt.lines[vt.cursorY]); i++ {
-			vt.lines[vt.cursorY][i] = bunt.ColoredRune{Symbol: ' '}
+// escape dispatches the byte following an escape character
+func (vt *VirtualTerminal) escape(b byte) {
+	vt.state = stateGround
+
+	switch b {
+	case '[':
+		vt.state = stateCSI
+		vt.sequence = vt.sequence[:0]
+
+	case ']':
+		vt.state = stateOSC
+
+	case 'P', 'X', '^', '_': // DCS, SOS, PM, APC
+		vt.state = stateString
+
+	case '(', ')', '*', '+', '#', ' ', '%':
+		vt.state = stateEscapeIntermediate
+		vt.intermediate = b
+
+	case '7': // DECSC
+		vt.saveCursor()
+
+	case '8': // DECRC
+		vt.restoreCursor()
+
+	case 'D': // IND
+		vt.lineFeed()
+
+	case 'E': // NEL
+		vt.carriageReturn()
+		vt.lineFeed()
+
+	case 'M': // RI
+		vt.reverseLineFeed()
+
+	case 'H': // HTS
+		vt.tabStops[vt.cursor.x] = struct{}{}
+
+	case 'c': // RIS
+		vt.reset()
+	}
+}
+
+// escapeIntermediate handles escape sequences with an intermediate byte,
+// which are mostly character set designations
+func (vt *VirtualTerminal) escapeIntermediate(b byte) {
+	vt.state = stateGround
+
+	switch vt.intermediate {
+	case '(', ')', '*', '+':
+		if g := int(vt.intermediate - '('); g < len(vt.cursor.lineDrawing) {
+			vt.cursor.lineDrawing[g] = b == '0'
 		}
 	}
 }
 
-// clearLine clears the entire current line
-func (vt *VirtualTerminal) clearLine() {
-	if vt.cursorY < len(vt.lines) {
-		vt.lines[vt.cursorY] = []bunt.ColoredRune{}
+// csi dispatches a control sequence with the given final byte
+func (vt *VirtualTerminal) csi(final byte) {
+	var private byte
+	var seq = string(vt.sequence)
+	if len(seq) > 0 && strings.ContainsRune("?<=>", rune(seq[0])) {
+		private, seq = seq[0], seq[1:]
+	}
+
+	var intermediates string
+	if idx := strings.IndexFunc(seq, func(r rune) bool { return r >= 0x20 && r <= 0x2F }); idx >= 0 {
+		intermediates, seq = seq[idx:], seq[:idx]
+	}
+
+	params := parseParams(seq)
+
+	switch {
+	case private == '?' && intermediates == "":
+		switch final {
+		case 'h':
+			vt.setPrivateModes(params, true)
+
+		case 'l':
+			vt.setPrivateModes(params, false)
+
+		case 'J': // DECSED
+			vt.eraseDisplay(params.get(0, 0))
+
+		case 'K': // DECSEL
+			vt.eraseLine(params.get(0, 0))
+		}
+
+	case private == 0 && intermediates == "!" && final == 'p': // DECSTR
+		vt.softReset()
+
+	case private == 0 && intermediates == "":
+		vt.dispatch(final, params)
 	}
 }
 
-// clearToEndOfScreen clears from cursor to end of screen
-func (vt *VirtualTerminal) clearToEndOfScreen() {
-	vt.clearToEndOfLine()
-	if vt.cursorY+1 < len(vt.lines) {
-		vt.lines = vt.lines[:vt.cursorY+1]
+// dispatch executes a standard control sequence
+func (vt *VirtualTerminal) dispatch(final byte, params params) {
+	var n = params.get(0, 1)
+	if n < 1 {
+		n = 1
+	}
+
+	switch final {
+	case '@': // ICH
+		vt.insertCharacters(n)
+
+	case 'A': // CUU
+		vt.moveTo(vt.cursor.x, max(vt.cursor.y-n, vt.upperLimit()))
+
+	case 'B': // CUD
+		vt.moveTo(vt.cursor.x, vt.lowerLimit(vt.cursor.y+n))
+
+	case 'C', 'a': // CUF, HPR
+		vt.moveTo(vt.cursor.x+n, vt.cursor.y)
+
+	case 'D': // CUB
+		vt.moveTo(vt.cursor.x-n, vt.cursor.y)
+
+	case 'E': // CNL
+		vt.moveTo(0, vt.lowerLimit(vt.cursor.y+n))
+
+	case 'F': // CPL
+		vt.moveTo(0, max(vt.cursor.y-n, vt.upperLimit()))
+
+	case 'G', '`': // CHA, HPA
+		vt.moveTo(n-1, vt.cursor.y)
+
+	case 'H', 'f': // CUP, HVP
+		vt.moveToOrigin(params.get(1, 1)-1, params.get(0, 1)-1)
+
+	case 'I': // CHT
+		vt.tab(n)
+
+	case 'J': // ED
+		vt.eraseDisplay(params.get(0, 0))
+
+	case 'K': // EL
+		vt.eraseLine(params.get(0, 0))
+
+	case 'L': // IL
+		vt.insertLines(n)
+
+	case 'M': // DL
+		vt.deleteLines(n)
+
+	case 'P': // DCH
+		vt.deleteCharacters(n)
+
+	case 'S': // SU
+		vt.scrollUp(n)
+
+	case 'T': // SD, with more parameters it is mouse tracking
+		if len(params) <= 1 {
+			vt.scrollDown(n)
+		}
+
+	case 'X': // ECH
+		vt.eraseCharacters(n)
+
+	case 'Z': // CBT
+		vt.tab(-n)
+
+	case 'b': // REP
+		if vt.lastPrinted != 0 {
+			for i := 0; i < n; i++ {
+				vt.print(vt.lastPrinted)
+			}
+		}
+
+	case 'd': // VPA
+		vt.moveToOrigin(vt.cursor.x, n-1)
+
+	case 'e': // VPR
+		vt.moveTo(vt.cursor.x, vt.lowerLimit(vt.cursor.y+n))
+
+	case 'g': // TBC
+		switch params.get(0, 0) {
+		case 0:
+			delete(vt.tabStops, vt.cursor.x)
+
+		case 3:
+			vt.tabStops = map[int]struct{}{}
+		}
+
+	case 'h', 'l': // SM, RM
+		for _, mode := range params {
+			switch mode.value {
+			case 4: // IRM
+				vt.insertMode = final == 'h'
+
+			case 20: // LNM
+				vt.newLineMode = final == 'h'
+			}
+		}
+
+	case 'm': // SGR
+		vt.cursor.attributes.apply(params)
+
+	case 'r': // DECSTBM
+		vt.setScrollRegion(params.get(0, 1)-1, params.get(1, 0)-1)
+
+	case 's': // SCOSC
+		vt.saveCursor()
+
+	case 'u': // SCORC
+		vt.restoreCursor()
 	}
 }
 
-// clearToBeginningOfScreen clears from beginning of screen to cursor
-func (vt *VirtualTerminal) clearToBeginningOfScreen() {
-	for i := 0; i < vt.cursorY && i < len(vt.lines); i++ {
-		vt.lines[i] = []bunt.ColoredRune{}
+// setPrivateModes sets or resets DEC private modes
+func (vt *VirtualTerminal) setPrivateModes(params params, enable bool) {
+	for _, mode := range params {
+		switch mode.value {
+		case 6: // DECOM
+			vt.cursor.originMode = enable
+			vt.moveToOrigin(0, 0)
+
+		case 7: // DECAWM
+			vt.autoWrap = enable
+			vt.wrapPending = false
+
+		case 25: // DECTCEM
+			vt.cursorVisible = enable
+
+		case 47, 1047: // alternate screen buffer
+			if !enable && mode.value == 1047 && vt.active == vt.alternate {
+				vt.keepAlternate()
+				vt.alternate.lines = vt.blankLines(max(vt.rows, 1))
+			}
+
+			vt.switchBuffer(enable)
+
+		case 1048: // save or restore cursor
+			if enable {
+				vt.saveCursor()
+			} else {
+				vt.restoreCursor()
+			}
+
+		case 1049: // save cursor and use cleared alternate screen buffer
+			switch {
+			case enable && vt.active == vt.primary:
+				vt.saveCursor()
+				vt.keepAlternate()
+				vt.alternate.lines = vt.blankLines(max(vt.rows, 1))
+				vt.switchBuffer(true)
+
+			case !enable && vt.active == vt.alternate:
+				vt.switchBuffer(false)
+				vt.restoreCursor()
+			}
+		}
 	}
-	vt.clearToBeginningOfLine()
 }
 
-// clearScreen clears the entire screen
-func (vt *VirtualTerminal) clearScreen() {
-	vt.lines = [][]bunt.ColoredRune{{}}
-	vt.cursorX = 0
-	vt.cursorY = 0
+// softReset resets modes, margins, and text attributes, but keeps the
+// screen content
+func (vt *VirtualTerminal) softReset() {
+	vt.cursor.attributes = attributes{}
+	vt.cursor.originMode = false
+	vt.cursor.lineDrawing = [2]bool{}
+	vt.cursor.shift = 0
+	vt.autoWrap = true
+	vt.insertMode = false
+	vt.cursorVisible = true
+	vt.scrollTop, vt.scrollBottom = 0, vt.rows-1
+	vt.active.saved = nil
 }
 
-// toBuntString converts the virtual terminal buffer to a bunt.String
-func (vt *VirtualTerminal) toBuntString() *bunt.String {
-	var result bunt.String
+// param is a numeric control sequence parameter with optional sub-parameters
+// (separated by colons)
+type param struct {
+	value   int
+	present bool
+	sub     []int
+}
 
-	for lineIdx, line := range vt.lines {
-		for _, cr := range line {
-			result = append(result, cr)
-		}
-		
-		// Add newline except for the last line
-		if lineIdx < len(vt.lines)-1 {
-			// Use the settings from the last character on the line, or default
-			var settings uint64
-			if len(line) > 0 {
-				settings = line[len(line)-1].Settings
+type params []param
+
+// maxParamValue is the largest value of a parameter, larger values are capped
+// like xterm does, so that sequences cannot move or insert endlessly
+const maxParamValue = 65535
+
+// parseParams parses semicolon separated parameters of control sequences
+func parseParams(seq string) params {
+	if seq == "" {
+		return nil
+	}
+
+	var result params
+	for _, field := range strings.Split(seq, ";") {
+		var p param
+		for i, part := range strings.Split(field, ":") {
+			value, err := strconv.Atoi(part)
+			switch {
+			case i == 0:
+				p.value, p.present = min(value, maxParamValue), err == nil
+
+			default:
+				p.sub = append(p.sub, min(value, maxParamValue))
 			}
-			result = append(result, bunt.ColoredRune{Symbol: '\n', Settings: settings})
 		}
+
+		result = append(result, p)
+	}
+
+	return result
+}
+
+// get returns the parameter with the given index, or the default value in
+// case the parameter is omitted or zero
+func (p params) get(idx int, defaultValue int) int {
+	if idx >= len(p) || !p[idx].present || p[idx].value == 0 {
+		return defaultValue
 	}
 
-	return &result
+	return p[idx].value
 }
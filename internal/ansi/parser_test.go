@@ -0,0 +1,210 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ansi_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/ansi"
+)
+
+var _ = Describe("Virtual terminal", func() {
+	Context("processing text", func() {
+		It("should treat line feeds as new lines", func() {
+			Expect(screen(80, 0, "foo\nbar\r\nbaz\n")).To(Equal("foo\nbar\nbaz"))
+		})
+
+		It("should overwrite text after a carriage return", func() {
+			Expect(screen(80, 0, "foobar\rBA")).To(Equal("BAobar"))
+		})
+
+		It("should wrap lines at the last column", func() {
+			Expect(screen(4, 0, "foobar")).To(Equal("foob\nar"))
+			Expect(screen(4, 0, "foob\r\nar")).To(Equal("foob\nar"))
+		})
+
+		It("should not wrap lines with auto wrap disabled", func() {
+			Expect(screen(4, 0, "\x1b[?7lfoobar")).To(Equal("foor"))
+		})
+
+		It("should keep lines scrolled off the screen", func() {
+			Expect(screen(80, 2, "a\nb\nc\nd")).To(Equal("a\nb\nc\nd"))
+		})
+
+		It("should omit the scrollback buffer when configured", func() {
+			vt := NewVirtualTerminal(80, 2).Scrollback(false)
+			_, _ = vt.Write([]byte("a\nb\nc\nd"))
+			Expect(text(vt.Content())).To(Equal("c\nd"))
+		})
+
+		It("should use tab stops", func() {
+			Expect(screen(80, 0, "a\tb")).To(Equal("a       b"))
+			Expect(screen(80, 0, "\x1b[3g\x1b[4C\x1bH\ra\tb")).To(Equal("a   b"))
+		})
+
+		It("should map line drawing characters", func() {
+			Expect(screen(80, 0, "\x1b(0lqk\x1b(B")).To(Equal("┌─┐"))
+		})
+	})
+
+	Context("processing cursor movements", func() {
+		It("should position the cursor", func() {
+			Expect(screen(10, 3, "\x1b[2;3Hx\x1b[1;1Hy")).To(Equal("y\n  x"))
+		})
+
+		It("should save and restore the cursor", func() {
+			Expect(screen(10, 3, "ab\x1b[s\x1b[3;1Hc\x1b[ud")).To(Equal("abd\n\nc"))
+			Expect(screen(10, 3, "ab\x1b7\x1b[3;1Hc\x1b8d")).To(Equal("abd\n\nc"))
+		})
+
+		It("should limit large parameters on a growing screen", func() {
+			Expect(strings.Count(screen(10, 0, "a\x1b[99999999Bb"), "\n")).To(Equal(9999))
+			Expect(screen(10, 0, "a\x1b[999999999Lb")).To(Equal("b"))
+			Expect(screen(10, 0, "a\x1b[1;99999999r\x1b[99999999Lb")).To(Equal("b"))
+		})
+
+		It("should ignore overlong control sequences", func() {
+			Expect(screen(10, 0, "a\x1b["+strings.Repeat("1;", 1000)+"2Jb")).To(Equal("ab"))
+			Expect(screen(10, 0, "a\x1b["+strings.Repeat("1", 1000)+"Cb")).To(Equal("ab"))
+		})
+
+		It("should keep all lines of long output on a growing screen", func() {
+			Expect(strings.Count(screen(10, 0, strings.Repeat("x\n", 10005)), "x")).To(Equal(10005))
+		})
+	})
+
+	Context("processing editing sequences", func() {
+		It("should erase in line and display", func() {
+			Expect(screen(10, 3, "foobar\x1b[3D\x1b[K")).To(Equal("foo"))
+			Expect(screen(10, 3, "foo\nbar\x1b[1;2H\x1b[J")).To(Equal("f"))
+			Expect(screen(10, 3, "foo\nbar\x1b[2J")).To(Equal(""))
+		})
+
+		It("should insert and delete characters", func() {
+			Expect(screen(10, 3, "foobar\x1b[1;4H\x1b[2@xx")).To(Equal("fooxxbar"))
+			Expect(screen(10, 3, "foobar\x1b[1;4H\x1b[2P")).To(Equal("foor"))
+			Expect(screen(10, 3, "foobar\x1b[1;4H\x1b[2X")).To(Equal("foo  r"))
+		})
+
+		It("should insert and delete lines", func() {
+			Expect(screen(10, 3, "a\nb\nc\x1b[2;1H\x1b[L")).To(Equal("a\n\nb"))
+			Expect(screen(10, 3, "a\nb\nc\x1b[1;1H\x1b[M")).To(Equal("b\nc"))
+		})
+
+		It("should scroll within the scroll region", func() {
+			Expect(screen(10, 4, "\x1b[4;1Hstatus\x1b[1;3r\x1b[3;1Ha\nb\nc\nd")).To(Equal("b\nc\nd\nstatus"))
+			Expect(screen(10, 3, "a\nb\nc\x1b[2;3r\x1b[2;1H\x1bM")).To(Equal("a\n\nb"))
+		})
+
+		It("should ignore scroll regions below the content of a growing screen", func() {
+			Expect(screen(10, 0, "hello\n\x1b[100r\x1b[S")).To(Equal("hello"))
+			Expect(screen(10, 0, "hello\n\x1b[100r\x1b[T")).To(Equal("\nhello"))
+		})
+	})
+
+	Context("processing the alternate screen", func() {
+		It("should restore the primary screen when leaving the alternate screen", func() {
+			Expect(screen(10, 3, "foo\x1b[?1049h\x1b[Hbar\x1b[?1049lx")).To(Equal("foox"))
+		})
+
+		It("should show the alternate screen while it is active", func() {
+			Expect(screen(10, 3, "foo\x1b[?1049h\x1b[Hbar")).To(Equal("bar"))
+		})
+
+		It("should show the primary screen when selected", func() {
+			vt := NewVirtualTerminal(10, 2).Show(PrimaryBuffer)
+			_, _ = vt.Write([]byte("a\nb\nc\x1b[?1049h\x1b[Hbar"))
+			Expect(text(vt.Content())).To(Equal("a\nb\nc"))
+		})
+
+		It("should show the last frame of the alternate screen when selected", func() {
+			vt := NewVirtualTerminal(10, 3).Show(AlternateBuffer)
+			_, _ = vt.Write([]byte("foo\x1b[?1049h\x1b[Hbar\x1b[?1049lx"))
+			Expect(text(vt.Content())).To(Equal("bar"))
+
+			vt = NewVirtualTerminal(10, 3).Show(AlternateBuffer)
+			_, _ = vt.Write([]byte("foo\x1b[?1049h\x1b[Hbar\x1b[2J\x1b[?1049l"))
+			Expect(text(vt.Content())).To(Equal("bar"))
+		})
+	})
+
+	Context("processing text attributes", func() {
+		It("should apply colors and text emphasis", func() {
+			vt := NewVirtualTerminal(10, 1)
+			_, _ = vt.Write([]byte("\x1b[1;31ma\x1b[0;38;5;196mb\x1b[38;2;1;2;3;48:2::4:5:6mc"))
+
+			content := vt.Content()
+			Expect(content).To(HaveLen(3))
+			Expect(content[0].Settings).To(Equal(uint64(0x04 | 0x01 | 0x20 | 1<<8)))
+			Expect(content[1].Settings).To(Equal(uint64(0x01 | 0x20 | 196<<8)))
+			Expect(content[2].Settings).To(Equal(uint64(0x01 | 0x02 | 1<<8 | 2<<16 | 3<<24 | 4<<32 | 5<<40 | 6<<48)))
+		})
+
+		It("should keep the palette index of colors", func() {
+			vt := NewVirtualTerminal(10, 1)
+			_, _ = vt.Write([]byte("\x1b[38;5;196;48;5;9ma\x1b[0;38;2;255;0;0mb"))
+
+			content := vt.Content()
+			fg, ok := Foreground(content[0].Settings)
+			Expect(ok).To(BeTrue())
+			Expect(fg).To(Equal(Color{Index: 196, RGB: [3]uint8{255, 0, 0}}))
+
+			bg, ok := Background(content[0].Settings)
+			Expect(ok).To(BeTrue())
+			Expect(bg).To(Equal(Color{Index: 9, RGB: [3]uint8{255, 0, 0}}))
+
+			fg, ok = Foreground(content[1].Settings)
+			Expect(ok).To(BeTrue())
+			Expect(fg).To(Equal(Color{Index: -1, RGB: [3]uint8{255, 0, 0}}))
+
+			_, ok = Background(content[1].Settings)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should swap palette colors with reverse video", func() {
+			vt := NewVirtualTerminal(10, 1)
+			_, _ = vt.Write([]byte("\x1b[7;38;5;100ma"))
+
+			fg, _ := Foreground(vt.Content()[0].Settings)
+			bg, _ := Background(vt.Content()[0].Settings)
+			Expect(fg.Index).To(Equal(0))
+			Expect(bg.Index).To(Equal(100))
+		})
+
+		It("should render the content with escape sequences", func() {
+			vt := NewVirtualTerminal(10, 1)
+			_, _ = vt.Write([]byte("\x1b[1;31mfoo\x1b[m \x1b[92;48;5;100mbar\x1b[38;2;1;2;3mbaz"))
+			Expect(Render(vt.Content())).To(Equal("\x1b[0;1;31mfoo\x1b[0m \x1b[0;92;48;5;100mbar\x1b[0;38;2;1;2;3;48;5;100mbaz\x1b[0m"))
+		})
+
+		It("should process sequences split across writes", func() {
+			vt := NewVirtualTerminal(10, 1)
+			for _, chunk := range strings.SplitAfter("\x1b[31mgrün\x1b[0m", "") {
+				_, _ = vt.Write([]byte(chunk))
+			}
+
+			Expect(text(vt.Content())).To(Equal("grün"))
+		})
+	})
+})
@@ -0,0 +1,507 @@
+package ansi
+
+import (
+	"unicode"
+
+	"github.com/gonvenience/bunt"
+	"golang.org/x/text/width"
+)
+
+// maxGrowingRows is the number of rows a screen without a fixed number of rows
+// grows to at most, further lines scroll into the scrollback buffer
+const maxGrowingRows = 10000
+
+// line is one row of the screen with exactly one cell per column, the second
+// cell of a wide character has the zero rune as its symbol
+type line []bunt.ColoredRune
+
+// buffer is a screen buffer (primary or alternate) with its own saved cursor
+type buffer struct {
+	lines []line
+	saved *cursor
+}
+
+// cursor is the position and the state that is stored and restored when
+// saving the cursor (DECSC)
+type cursor struct {
+	x, y       int
+	attributes attributes
+	originMode bool
+
+	// character sets G0 and G1, and the one shifted in
+	lineDrawing [2]bool
+	shift       int
+}
+
+// blank returns whether the line only consists of blank cells
+func (l line) blank() bool {
+	return len(l.trimmed()) == 0
+}
+
+// blank returns whether all lines only consist of blank cells
+func blank(lines []line) bool {
+	for _, l := range lines {
+		if !l.blank() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trimmed returns the line without trailing blank cells, cells with a
+// background color are not considered blank
+func (l line) trimmed() line {
+	end := len(l)
+	for end > 0 && (l[end-1].Symbol == ' ' || l[end-1].Symbol == 0) && l[end-1].Settings&bgMask == 0 {
+		end--
+	}
+
+	return l[:end]
+}
+
+// blankCell returns an empty cell using the current background color
+func (vt *VirtualTerminal) blankCell() bunt.ColoredRune {
+	return bunt.ColoredRune{Symbol: ' ', Settings: vt.cursor.attributes.settings & bgSettings}
+}
+
+// blankLines returns the given number of empty lines
+func (vt *VirtualTerminal) blankLines(n int) []line {
+	result := make([]line, n)
+	for i := range result {
+		result[i] = make(line, vt.columns)
+		for x := range result[i] {
+			result[i][x] = vt.blankCell()
+		}
+	}
+
+	return result
+}
+
+// line returns the line with the given row, the screen grows in case the
+// number of rows is not fixed
+func (vt *VirtualTerminal) line(y int) line {
+	if missing := y + 1 - len(vt.active.lines); missing > 0 {
+		vt.active.lines = append(vt.active.lines, vt.blankLines(missing)...)
+	}
+
+	return vt.active.lines[y]
+}
+
+// lastRow returns the last row of the screen, which is the limit up to which
+// the screen grows in case the number of rows is not fixed
+func (vt *VirtualTerminal) lastRow() int {
+	if vt.rows > 0 {
+		return vt.rows - 1
+	}
+
+	return maxGrowingRows - 1
+}
+
+// bottom returns the last row of the scroll region, which is the last row
+// of the screen when no scroll region is set
+func (vt *VirtualTerminal) bottom() int {
+	if vt.scrollBottom >= 0 {
+		return vt.scrollBottom
+	}
+
+	return vt.lastRow()
+}
+
+// regionBottom returns the last row of the scroll region for operations that
+// move lines within the region, in case the screen grows this is the given
+// number of lines below the last line
+func (vt *VirtualTerminal) regionBottom(n int) int {
+	if vt.scrollBottom >= 0 {
+		vt.line(vt.scrollBottom)
+		return vt.scrollBottom
+	}
+
+	vt.line(min(len(vt.active.lines)-1+n, vt.lastRow()))
+	return len(vt.active.lines) - 1
+}
+
+// upperLimit returns the topmost row the cursor can be moved to with relative
+// cursor movements
+func (vt *VirtualTerminal) upperLimit() int {
+	if vt.cursor.y >= vt.scrollTop {
+		return vt.scrollTop
+	}
+
+	return 0
+}
+
+// lowerLimit returns the given row limited to the bottom of the scroll
+// region, or the screen in case the cursor is below the scroll region
+func (vt *VirtualTerminal) lowerLimit(y int) int {
+	switch {
+	case vt.scrollBottom >= 0 && vt.cursor.y <= vt.scrollBottom:
+		return min(y, vt.scrollBottom)
+
+	default:
+		return min(y, vt.lastRow())
+	}
+}
+
+// moveTo moves the cursor to the given position, limited to the screen
+func (vt *VirtualTerminal) moveTo(x int, y int) {
+	vt.cursor.x = max(0, min(x, vt.columns-1))
+	vt.cursor.y = max(0, min(y, vt.lastRow()))
+
+	vt.wrapPending = false
+	vt.line(vt.cursor.y)
+}
+
+// moveToOrigin moves the cursor to the given absolute position, which is
+// relative to the scroll region in origin mode
+func (vt *VirtualTerminal) moveToOrigin(x int, y int) {
+	if !vt.cursor.originMode {
+		vt.moveTo(x, y)
+		return
+	}
+
+	y += vt.scrollTop
+	if vt.scrollBottom >= 0 {
+		y = min(y, vt.scrollBottom)
+	}
+
+	vt.moveTo(x, y)
+}
+
+// carriageReturn moves the cursor to the first column
+func (vt *VirtualTerminal) carriageReturn() {
+	vt.moveTo(0, vt.cursor.y)
+}
+
+// lineFeed moves the cursor down one row, the scroll region scrolls up if
+// the cursor is at its bottom
+func (vt *VirtualTerminal) lineFeed() {
+	switch {
+	case vt.cursor.y == vt.bottom():
+		vt.scrollUp(1)
+		vt.wrapPending = false
+
+	default:
+		vt.moveTo(vt.cursor.x, vt.cursor.y+1)
+	}
+}
+
+// reverseLineFeed moves the cursor up one row, the scroll region scrolls
+// down if the cursor is at its top
+func (vt *VirtualTerminal) reverseLineFeed() {
+	switch {
+	case vt.cursor.y == vt.scrollTop:
+		vt.scrollDown(1)
+		vt.wrapPending = false
+
+	default:
+		vt.moveTo(vt.cursor.x, vt.cursor.y-1)
+	}
+}
+
+// tab moves the cursor to the next tab stop (or previous for negative n)
+func (vt *VirtualTerminal) tab(n int) {
+	x := vt.cursor.x
+	for ; n > 0; n-- {
+		for x++; x < vt.columns-1; x++ {
+			if _, ok := vt.tabStops[x]; ok {
+				break
+			}
+		}
+	}
+
+	for ; n < 0; n++ {
+		for x--; x > 0; x-- {
+			if _, ok := vt.tabStops[x]; ok {
+				break
+			}
+		}
+	}
+
+	vt.moveTo(x, vt.cursor.y)
+}
+
+// print writes the character at the cursor position and advances the cursor
+func (vt *VirtualTerminal) print(r rune) {
+	if vt.cursor.lineDrawing[vt.cursor.shift] {
+		if mapped, ok := decSpecialGraphics[r]; ok {
+			r = mapped
+		}
+	}
+
+	w := runeWidth(r)
+	if w == 0 {
+		return
+	}
+
+	vt.lastPrinted = r
+
+	if vt.wrapPending || (w > 1 && vt.cursor.x+w > vt.columns && vt.autoWrap) {
+		vt.carriageReturn()
+		vt.lineFeed()
+	}
+
+	if vt.insertMode {
+		vt.insertCharacters(w)
+	}
+
+	var settings = vt.cursor.attributes.cellSettings()
+	if vt.cursor.attributes.conceal {
+		r = ' '
+	}
+
+	l := vt.line(vt.cursor.y)
+	l[vt.cursor.x] = bunt.ColoredRune{Symbol: r, Settings: settings}
+	if w > 1 && vt.cursor.x+1 < vt.columns {
+		l[vt.cursor.x+1] = bunt.ColoredRune{Symbol: 0, Settings: settings}
+	}
+
+	switch {
+	case vt.cursor.x+w < vt.columns:
+		vt.cursor.x += w
+
+	default:
+		vt.cursor.x = vt.columns - 1
+		vt.wrapPending = vt.autoWrap
+	}
+}
+
+// eraseDisplay erases parts of the screen (ED)
+func (vt *VirtualTerminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0: // from cursor to end of screen
+		vt.eraseLine(0)
+		for y := vt.cursor.y + 1; y < len(vt.active.lines); y++ {
+			vt.eraseCells(y, 0, vt.columns)
+		}
+
+	case 1: // from beginning of screen to cursor
+		for y := 0; y < vt.cursor.y; y++ {
+			vt.eraseCells(y, 0, vt.columns)
+		}
+		vt.eraseLine(1)
+
+	case 2: // entire screen
+		if vt.active == vt.alternate {
+			vt.keepAlternate()
+		}
+
+		for y := range vt.active.lines {
+			vt.eraseCells(y, 0, vt.columns)
+		}
+
+	case 3: // scrollback buffer
+		vt.scrollback = nil
+	}
+}
+
+// eraseLine erases parts of the current line (EL)
+func (vt *VirtualTerminal) eraseLine(mode int) {
+	switch mode {
+	case 0: // from cursor to end of line
+		vt.eraseCells(vt.cursor.y, vt.cursor.x, vt.columns)
+
+	case 1: // from beginning of line to cursor
+		vt.eraseCells(vt.cursor.y, 0, vt.cursor.x+1)
+
+	case 2: // entire line
+		vt.eraseCells(vt.cursor.y, 0, vt.columns)
+	}
+
+	vt.wrapPending = false
+}
+
+// eraseCharacters erases characters starting at the cursor (ECH)
+func (vt *VirtualTerminal) eraseCharacters(n int) {
+	vt.eraseCells(vt.cursor.y, vt.cursor.x, min(vt.cursor.x+n, vt.columns))
+	vt.wrapPending = false
+}
+
+// eraseCells replaces the cells of a line in the given range with blanks
+func (vt *VirtualTerminal) eraseCells(y int, from int, to int) {
+	l := vt.line(y)
+	for x := from; x < to && x < len(l); x++ {
+		l[x] = vt.blankCell()
+	}
+}
+
+// insertCharacters inserts blank characters at the cursor, the characters
+// moved beyond the right margin are lost (ICH)
+func (vt *VirtualTerminal) insertCharacters(n int) {
+	l := vt.line(vt.cursor.y)
+	n = min(n, vt.columns-vt.cursor.x)
+	copy(l[vt.cursor.x+n:], l[vt.cursor.x:])
+	vt.eraseCells(vt.cursor.y, vt.cursor.x, vt.cursor.x+n)
+	vt.wrapPending = false
+}
+
+// deleteCharacters deletes characters at the cursor, the remaining
+// characters move to the left (DCH)
+func (vt *VirtualTerminal) deleteCharacters(n int) {
+	l := vt.line(vt.cursor.y)
+	n = min(n, vt.columns-vt.cursor.x)
+	copy(l[vt.cursor.x:], l[vt.cursor.x+n:])
+	vt.eraseCells(vt.cursor.y, vt.columns-n, vt.columns)
+	vt.wrapPending = false
+}
+
+// insertLines inserts blank lines at the cursor row, lines moved beyond the
+// bottom of the scroll region are lost (IL)
+func (vt *VirtualTerminal) insertLines(n int) {
+	if vt.cursor.y < vt.scrollTop || (vt.scrollBottom >= 0 && vt.cursor.y > vt.scrollBottom) {
+		return
+	}
+
+	vt.shiftDown(vt.cursor.y, vt.regionBottom(n), n)
+	vt.carriageReturn()
+}
+
+// deleteLines deletes lines at the cursor row, blank lines are added at the
+// bottom of the scroll region (DL)
+func (vt *VirtualTerminal) deleteLines(n int) {
+	if vt.cursor.y < vt.scrollTop || (vt.scrollBottom >= 0 && vt.cursor.y > vt.scrollBottom) {
+		return
+	}
+
+	vt.shiftUp(vt.cursor.y, vt.regionBottom(0), n)
+	vt.carriageReturn()
+}
+
+// scrollUp moves the content of the scroll region up, lines moved off the top
+// of the primary screen are added to the scrollback buffer in case the scroll
+// region is the whole screen (SU)
+func (vt *VirtualTerminal) scrollUp(n int) {
+	bottom := vt.regionBottom(0)
+	if vt.scrollTop == 0 && vt.scrollBottom == vt.rows-1 && vt.active == vt.primary {
+		for i := 0; i < n && i <= bottom; i++ {
+			vt.scrollback = append(vt.scrollback, append(line{}, vt.active.lines[i]...))
+		}
+	}
+
+	vt.shiftUp(vt.scrollTop, bottom, n)
+}
+
+// scrollDown moves the content of the scroll region down (SD)
+func (vt *VirtualTerminal) scrollDown(n int) {
+	vt.shiftDown(vt.scrollTop, vt.regionBottom(n), n)
+}
+
+// shiftUp moves the lines in the given range up by n lines
+func (vt *VirtualTerminal) shiftUp(top int, bottom int, n int) {
+	n = min(n, bottom-top+1)
+	if n <= 0 {
+		return
+	}
+
+	lines := vt.active.lines
+	copy(lines[top:bottom+1], lines[top+n:bottom+1])
+	copy(lines[bottom+1-n:bottom+1], vt.blankLines(n))
+}
+
+// shiftDown moves the lines in the given range down by n lines
+func (vt *VirtualTerminal) shiftDown(top int, bottom int, n int) {
+	n = min(n, bottom-top+1)
+	if n <= 0 {
+		return
+	}
+
+	lines := vt.active.lines
+	copy(lines[top+n:bottom+1], lines[top:bottom+1-n])
+	copy(lines[top:top+n], vt.blankLines(n))
+}
+
+// setScrollRegion sets the top and bottom rows of the scroll region and
+// moves the cursor to the home position (DECSTBM)
+func (vt *VirtualTerminal) setScrollRegion(top int, bottom int) {
+	if bottom < 0 {
+		bottom = vt.rows - 1
+	}
+
+	if bottom >= 0 {
+		bottom = min(bottom, vt.lastRow())
+	}
+
+	if bottom >= 0 && top >= bottom {
+		return
+	}
+
+	// a region without a bottom on a growing screen has to start within
+	// the lines of the screen
+	if bottom < 0 && top >= len(vt.active.lines) {
+		return
+	}
+
+	vt.scrollTop, vt.scrollBottom = top, bottom
+	vt.moveToOrigin(0, 0)
+}
+
+// saveCursor stores the cursor of the active buffer (DECSC)
+func (vt *VirtualTerminal) saveCursor() {
+	saved := vt.cursor
+	vt.active.saved = &saved
+}
+
+// restoreCursor restores the saved cursor of the active buffer, or moves the
+// cursor home in case there is none (DECRC)
+func (vt *VirtualTerminal) restoreCursor() {
+	switch vt.active.saved {
+	case nil:
+		vt.cursor = cursor{}
+
+	default:
+		vt.cursor = *vt.active.saved
+	}
+
+	vt.moveTo(vt.cursor.x, vt.cursor.y)
+}
+
+// switchBuffer switches between the primary and the alternate screen buffer
+func (vt *VirtualTerminal) switchBuffer(alternate bool) {
+	switch alternate {
+	case true:
+		vt.active = vt.alternate
+
+	default:
+		vt.active = vt.primary
+	}
+
+	vt.moveTo(vt.cursor.x, vt.cursor.y)
+}
+
+// keepAlternate stores a copy of the alternate screen before it is cleared,
+// so that the last frame of a full-screen program can still be rendered
+func (vt *VirtualTerminal) keepAlternate() {
+	if blank(vt.alternate.lines) {
+		return
+	}
+
+	vt.lastAlternate = make([]line, len(vt.alternate.lines))
+	for i, l := range vt.alternate.lines {
+		vt.lastAlternate[i] = append(line{}, l...)
+	}
+}
+
+// runeWidth returns the number of cells the rune occupies on the screen
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+// decSpecialGraphics maps the DEC special graphics character set, which is
+// used for line drawing, to the respective Unicode characters
+var decSpecialGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'b': '␉', 'c': '␌', 'd': '␍', 'e': '␊', 'f': '°', 'g': '±',
+	'h': '␤', 'i': '␋', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└', 'n': '┼', 'o': '⎺',
+	'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├', 'u': '┤', 'v': '┴', 'w': '┬',
+	'x': '│', 'y': '≤', 'z': '≥', '{': 'π', '|': '≠', '}': '£', '~': '·',
+}
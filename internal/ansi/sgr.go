@@ -0,0 +1,364 @@
+package ansi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+)
+
+// Bit masks of the text settings used by bunt.ColoredRune
+const (
+	fgMask        = 0x01
+	bgMask        = 0x02
+	boldMask      = 0x04
+	italicMask    = 0x08
+	underlineMask = 0x10
+
+	// colors selected from the 256 color palette keep their index instead
+	// of the RGB value, so that they can be mapped to the theme colors
+	fgPaletteMask = 0x20
+	bgPaletteMask = 0x40
+
+	fgSettings = fgMask | fgPaletteMask | 0xFFFFFF<<8
+	bgSettings = bgMask | bgPaletteMask | 0xFFFFFF<<32
+)
+
+// Default colors, which are used for reverse video in case no explicit color
+// is set, they are the standard colors white and black
+var (
+	defaultForeground = paletteColor(7)
+	defaultBackground = paletteColor(0)
+)
+
+// standardColors are the typical RGB values of the 16 ANSI colors, which are
+// used for palette colors that are not defined by the theme
+var standardColors = [16][3]uint8{
+	{0, 0, 0}, {128, 0, 0}, {0, 128, 0}, {128, 128, 0},
+	{0, 0, 128}, {128, 0, 128}, {0, 128, 128}, {192, 192, 192},
+	{128, 128, 128}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{0, 0, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Color is the foreground or background color of a character
+type Color struct {
+	// Index is the index of the color in the 256 color palette, or -1 in
+	// case the color was set as an RGB value
+	Index int
+
+	// RGB is the RGB value of the color, palette colors use the typical
+	// xterm values
+	RGB [3]uint8
+}
+
+// Foreground returns the foreground color of the settings, in case one is set
+func Foreground(settings uint64) (Color, bool) {
+	return colorOf(settings, 8, fgMask, fgPaletteMask)
+}
+
+// Background returns the background color of the settings, in case one is set
+func Background(settings uint64) (Color, bool) {
+	return colorOf(settings, 32, bgMask, bgPaletteMask)
+}
+
+func colorOf(settings uint64, shift int, mask uint64, paletteMask uint64) (Color, bool) {
+	switch {
+	case settings&mask == 0:
+		return Color{}, false
+
+	case settings&paletteMask != 0:
+		index := int(uint8(settings >> shift))
+		return Color{Index: index, RGB: indexedColor(index)}, true
+
+	default:
+		return Color{Index: -1, RGB: [3]uint8{uint8(settings >> shift), uint8(settings >> (shift + 8)), uint8(settings >> (shift + 16))}}, true
+	}
+}
+
+func paletteColor(n int) Color {
+	return Color{Index: n, RGB: indexedColor(n)}
+}
+
+func rgbColor(r, g, b int) Color {
+	return Color{Index: -1, RGB: [3]uint8{uint8(r), uint8(g), uint8(b)}}
+}
+
+// bits returns the color in the form used by the settings, which is either
+// the palette index or the RGB value
+func (c Color) bits() uint64 {
+	if c.Index >= 0 {
+		return uint64(c.Index)
+	}
+
+	return uint64(c.RGB[0]) | uint64(c.RGB[1])<<8 | uint64(c.RGB[2])<<16
+}
+
+// Resolve returns the content with the RGB values of palette colors, so
+// that it can be rendered by bunt, which does not know about the palette
+func Resolve(content bunt.String) bunt.String {
+	var result = make(bunt.String, len(content))
+	for i, cr := range content {
+		if fg, ok := Foreground(cr.Settings); ok {
+			cr.Settings = withForeground(cr.Settings, rgbColor(int(fg.RGB[0]), int(fg.RGB[1]), int(fg.RGB[2])))
+		}
+
+		if bg, ok := Background(cr.Settings); ok {
+			cr.Settings = withBackground(cr.Settings, rgbColor(int(bg.RGB[0]), int(bg.RGB[1]), int(bg.RGB[2])))
+		}
+
+		result[i] = cr
+	}
+
+	return result
+}
+
+// attributes are the text attributes set by SGR sequences
+type attributes struct {
+	settings uint64
+	reverse  bool
+	conceal  bool
+}
+
+// cellSettings returns the settings of a printed character, with reverse
+// video the foreground and background colors are swapped
+func (a attributes) cellSettings() uint64 {
+	if !a.reverse {
+		return a.settings
+	}
+
+	fg, ok := Foreground(a.settings)
+	if !ok {
+		fg = defaultForeground
+	}
+
+	bg, ok := Background(a.settings)
+	if !ok {
+		bg = defaultBackground
+	}
+
+	return withBackground(withForeground(a.settings, bg), fg)
+}
+
+// apply changes the attributes according to the SGR parameters
+func (a *attributes) apply(params params) {
+	if len(params) == 0 {
+		*a = attributes{}
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		switch p := params[i].value; {
+		case p == 0:
+			*a = attributes{}
+
+		case p == 1:
+			a.settings |= boldMask
+
+		case p == 3:
+			a.settings |= italicMask
+
+		case p == 4:
+			// curly or other underline styles are rendered as regular underline
+			switch {
+			case len(params[i].sub) > 0 && params[i].sub[0] == 0:
+				a.settings &^= underlineMask
+
+			default:
+				a.settings |= underlineMask
+			}
+
+		case p == 7:
+			a.reverse = true
+
+		case p == 8:
+			a.conceal = true
+
+		case p == 21:
+			a.settings |= underlineMask
+
+		case p == 22:
+			a.settings &^= boldMask
+
+		case p == 23:
+			a.settings &^= italicMask
+
+		case p == 24:
+			a.settings &^= underlineMask
+
+		case p == 27:
+			a.reverse = false
+
+		case p == 28:
+			a.conceal = false
+
+		case p >= 30 && p <= 37:
+			a.settings = withForeground(a.settings, paletteColor(p-30))
+
+		case p == 38:
+			if c, ok := extendedColor(params, &i); ok {
+				a.settings = withForeground(a.settings, c)
+			}
+
+		case p == 39:
+			a.settings &^= fgSettings
+
+		case p >= 40 && p <= 47:
+			a.settings = withBackground(a.settings, paletteColor(p-40))
+
+		case p == 48:
+			if c, ok := extendedColor(params, &i); ok {
+				a.settings = withBackground(a.settings, c)
+			}
+
+		case p == 49:
+			a.settings &^= bgSettings
+
+		case p >= 90 && p <= 97:
+			a.settings = withForeground(a.settings, paletteColor(p-90+8))
+
+		case p >= 100 && p <= 107:
+			a.settings = withBackground(a.settings, paletteColor(p-100+8))
+		}
+	}
+}
+
+// extendedColor parses a 256 color or true color parameter, which is either
+// in the form 38;5;n and 38;2;r;g;b, or with sub-parameters like 38:2::r:g:b
+func extendedColor(params params, i *int) (Color, bool) {
+	var values []int
+	switch {
+	case len(params[*i].sub) > 0:
+		values = params[*i].sub
+		// the optional color space identifier of 38:2:id:r:g:b is skipped
+		if len(values) == 5 && values[0] == 2 {
+			values = append([]int{2}, values[2:]...)
+		}
+
+	default:
+		for _, p := range params[*i+1:] {
+			values = append(values, p.value)
+		}
+	}
+
+	switch {
+	case len(values) >= 2 && values[0] == 5:
+		if len(params[*i].sub) == 0 {
+			*i += 2
+		}
+
+		return paletteColor(values[1]), values[1] >= 0 && values[1] <= 255
+
+	case len(values) >= 4 && values[0] == 2:
+		if len(params[*i].sub) == 0 {
+			*i += 4
+		}
+
+		return rgbColor(values[1], values[2], values[3]), true
+	}
+
+	*i = len(params)
+	return Color{}, false
+}
+
+// indexedColor returns the RGB value of a color of the 256 color palette
+func indexedColor(n int) [3]uint8 {
+	switch {
+	case n < 16:
+		return standardColors[max(n, 0)]
+
+	case n < 232:
+		var levels = [6]uint8{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return [3]uint8{levels[n/36], levels[(n/6)%6], levels[n%6]}
+
+	default:
+		gray := uint8(8 + 10*(min(n, 255)-232))
+		return [3]uint8{gray, gray, gray}
+	}
+}
+
+func withForeground(settings uint64, c Color) uint64 {
+	settings = settings&^fgSettings | fgMask | c.bits()<<8
+	if c.Index >= 0 {
+		settings |= fgPaletteMask
+	}
+
+	return settings
+}
+
+func withBackground(settings uint64, c Color) uint64 {
+	settings = settings&^bgSettings | bgMask | c.bits()<<32
+	if c.Index >= 0 {
+		settings |= bgPaletteMask
+	}
+
+	return settings
+}
+
+// Render returns the content as text with SGR escape sequences, regardless
+// of whether the standard output supports colors, palette colors are kept
+// as such so that ParseStream restores them
+func Render(content bunt.String) string {
+	var sb strings.Builder
+	var current uint64
+	for _, cr := range content {
+		if cr.Settings != current {
+			sb.WriteString(sgr(cr.Settings))
+			current = cr.Settings
+		}
+
+		sb.WriteRune(cr.Symbol)
+	}
+
+	if current != 0 {
+		sb.WriteString(sgr(0))
+	}
+
+	return sb.String()
+}
+
+// sgr returns the escape sequence for the given settings, which always
+// starts with a reset so that it does not depend on the previous settings
+func sgr(settings uint64) string {
+	var params = []string{"0"}
+	if settings&boldMask != 0 {
+		params = append(params, "1")
+	}
+
+	if settings&italicMask != 0 {
+		params = append(params, "3")
+	}
+
+	if settings&underlineMask != 0 {
+		params = append(params, "4")
+	}
+
+	if fg, ok := Foreground(settings); ok {
+		params = append(params, sgrColor(fg, 30, 90, 38))
+	}
+
+	if bg, ok := Background(settings); ok {
+		params = append(params, sgrColor(bg, 40, 100, 48))
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// sgrColor returns the SGR parameters of a color, the 16 ANSI colors use the
+// standard and bright color parameters, other colors the extended ones
+func sgrColor(c Color, standard int, bright int, extended int) string {
+	switch {
+	case c.Index >= 0 && c.Index < 8:
+		return strconv.Itoa(standard + c.Index)
+
+	case c.Index >= 8 && c.Index < 16:
+		return strconv.Itoa(bright + c.Index - 8)
+
+	case c.Index >= 16:
+		return fmt.Sprintf("%d;5;%d", extended, c.Index)
+
+	default:
+		return fmt.Sprintf("%d;2;%d;%d;%d", extended, c.RGB[0], c.RGB[1], c.RGB[2])
+	}
+}
@@ -0,0 +1,193 @@
+package ansi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+)
+
+// ParseStream parses text with SGR escape sequences like bunt.ParseStream
+// does, but colors of the 256 color palette keep their index. Other than
+// the virtual terminal, the text is processed line by line: carriage
+// returns, backspaces, moving the cursor left and right, and erasing in
+// line are supported, all other escape sequences are ignored.
+func ParseStream(in io.Reader) (*bunt.String, error) {
+	var s = stream{input: bufio.NewReader(in)}
+	for {
+		r, _, err := s.input.ReadRune()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		switch r {
+		case '\x1b':
+			if err := s.escape(); err != nil {
+				return nil, err
+			}
+
+		case '\r':
+			s.x = 0
+
+		case '\n':
+			s.flush()
+			s.result = append(s.result, bunt.ColoredRune{Symbol: '\n'})
+
+		case '\b':
+			s.x = max(s.x-1, 0)
+
+		default:
+			// tabs are kept, since their width depends on the column they
+			// are rendered in
+			if (r < 0x20 && r != '\t') || r == 0x7F {
+				continue
+			}
+
+			if s.attributes.conceal {
+				r = ' '
+			}
+
+			s.put(bunt.ColoredRune{Symbol: r, Settings: s.attributes.cellSettings()})
+		}
+	}
+
+	s.flush()
+	return &s.result, nil
+}
+
+// maxStreamColumns is the column up to which the cursor can be moved to the
+// right beyond the end of the line, since the gap is filled with spaces
+const maxStreamColumns = 1024
+
+// stream is the state of ParseStream, the current line is only added to
+// the result with the next line feed
+type stream struct {
+	input      *bufio.Reader
+	attributes attributes
+	line       line
+	x          int
+	result     bunt.String
+}
+
+// put writes the character at the current position of the line
+func (s *stream) put(cr bunt.ColoredRune) {
+	for len(s.line) < s.x {
+		s.line = append(s.line, bunt.ColoredRune{Symbol: ' '})
+	}
+
+	switch {
+	case s.x < len(s.line):
+		s.line[s.x] = cr
+
+	default:
+		s.line = append(s.line, cr)
+	}
+
+	s.x++
+}
+
+// flush adds the current line without trailing blank cells to the result
+func (s *stream) flush() {
+	s.result = append(s.result, s.line.trimmed()...)
+	s.line, s.x = nil, 0
+}
+
+// escape processes an escape sequence, the escape character is already read
+func (s *stream) escape() error {
+	r, _, err := s.input.ReadRune()
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	switch {
+	case r == '[':
+		return s.csi()
+
+	case r == ']' || r == 'P' || r == 'X' || r == '^' || r == '_':
+		// operating system commands and other strings end with BEL or ST
+		for {
+			r, _, err := s.input.ReadRune()
+			switch {
+			case err == io.EOF, r == '\a':
+				return nil
+
+			case err != nil:
+				return fmt.Errorf("failed to read input: %w", err)
+
+			case r == '\x1b':
+				_, _, _ = s.input.ReadRune()
+				return nil
+			}
+		}
+
+	case r >= 0x20 && r <= 0x2F:
+		// sequences with intermediate characters, like the selection of
+		// character sets, end with the next character
+		_, _, _ = s.input.ReadRune()
+	}
+
+	return nil
+}
+
+// csi processes a control sequence, the introducer is already read
+func (s *stream) csi() error {
+	var sb strings.Builder
+	for {
+		r, _, err := s.input.ReadRune()
+		switch {
+		case err == io.EOF:
+			return nil
+
+		case err != nil:
+			return fmt.Errorf("failed to read input: %w", err)
+
+		case r < 0x40 || r > 0x7E:
+			sb.WriteRune(r)
+			continue
+		}
+
+		seq := sb.String()
+		if strings.ContainsAny(seq, "?<=> !\"#$%&'()*+,-./") {
+			// private and other sequences do not change the text
+			return nil
+		}
+
+		params := parseParams(seq)
+		switch r {
+		case 'm':
+			s.attributes.apply(params)
+
+		case 'C':
+			s.x = min(s.x+params.get(0, 1), max(len(s.line), maxStreamColumns))
+
+		case 'D':
+			s.x = max(s.x-params.get(0, 1), 0)
+
+		case 'K':
+			start, end := s.x, len(s.line)
+			switch params.get(0, 0) {
+			case 1:
+				start, end = 0, min(s.x+1, len(s.line))
+
+			case 2:
+				start = 0
+			}
+
+			for i := start; i < end; i++ {
+				s.line[i] = bunt.ColoredRune{Symbol: ' ', Settings: s.attributes.settings & bgSettings}
+			}
+		}
+
+		return nil
+	}
+}
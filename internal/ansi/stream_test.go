@@ -0,0 +1,76 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ansi_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/ansi"
+)
+
+var _ = Describe("Parsing streams", func() {
+	Context("processing text", func() {
+		It("should keep lines and remove trailing blanks", func() {
+			parsed, err := ParseStream(strings.NewReader("foo  \nbar\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text(*parsed)).To(Equal("foo\nbar\n"))
+		})
+
+		It("should overwrite text after a carriage return or moving the cursor", func() {
+			parsed, err := ParseStream(strings.NewReader("foobar\rF\x1b[2CB\x1b[KX\bx"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text(*parsed)).To(Equal("FooBx"))
+		})
+
+		It("should keep tabs", func() {
+			parsed, err := ParseStream(strings.NewReader("col1\tcol2\n\x1b[31mred\tcell\x1b[0m"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text(*parsed)).To(Equal("col1\tcol2\nred\tcell"))
+		})
+
+		It("should limit moving the cursor to the right", func() {
+			parsed, err := ParseStream(strings.NewReader("foo\x1b[999999999Cx" + strings.Repeat("\x1b[65535C", 100) + "y"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text(*parsed)).To(Equal("foo" + strings.Repeat(" ", 1021) + "xy"))
+		})
+
+		It("should ignore other escape sequences", func() {
+			parsed, err := ParseStream(strings.NewReader("\x1b]0;title\afoo\x1b[?25l\x1b(Bbar\x1b[2J"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text(*parsed)).To(Equal("foobar"))
+		})
+	})
+
+	Context("processing text attributes", func() {
+		It("should restore the content rendered with escape sequences", func() {
+			vt := NewVirtualTerminal(10, 2)
+			_, _ = vt.Write([]byte("\x1b[1;31mfoo\x1b[m \x1b[38;5;196;48;2;1;2;3mbar\r\n\x1b[4;7mbaz"))
+
+			content := vt.Content()
+			parsed, err := ParseStream(strings.NewReader(Render(content)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*parsed).To(Equal(content))
+		})
+	})
+})
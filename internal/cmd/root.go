@@ -22,11 +22,14 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/neat"
@@ -55,6 +58,12 @@ window including all terminal colors and text decorations.
 `,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+
+	// The arguments are the command to run, even though there are sub
+	// commands, which are only used without the '--' separator
+	Args:              cobra.ArbitraryArgs,
+	CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if showVersion, err := cmd.Flags().GetBool("version"); showVersion && err == nil {
 			if len(version) == 0 {
@@ -73,11 +82,16 @@ window including all terminal colors and text decorations.
 
 		rawRead, _ := cmd.Flags().GetString("raw-read")
 		rawWrite, _ := cmd.Flags().GetString("raw-write")
+		sessionFile, _ := cmd.Flags().GetString("session")
 
-		if len(args) == 0 && rawRead == "" {
+		if len(args) == 0 && rawRead == "" && sessionFile == "" {
 			return cmd.Usage()
 		}
 
+		if sessionFile != "" && (len(args) > 0 || rawRead != "") {
+			return fmt.Errorf("the --session flag cannot be combined with a command or --raw-read, the commands are read from the session file")
+		}
+
 		var scaffold = img.NewImageCreator()
 		var buf bytes.Buffer
 		var pt = ptexec.New()
@@ -90,7 +104,7 @@ window including all terminal colors and text decorations.
 		if themeFile != "" {
 			loadedTheme, err := theme.LoadThemeFromFile(themeFile)
 			if err != nil {
-				return fmt.Errorf("failed to load theme file: %w", err)
+				return err
 			}
 			selectedTheme = loadedTheme
 		} else {
@@ -129,6 +143,116 @@ window including all terminal colors and text decorations.
 			pt.SetShellOpts(shellOpts)
 		}
 
+		// Optional: Control the working directory and the environment of the
+		// command, so that screenshots are reproducible and do not leak
+		// details of the machine
+		//
+		if dir, err := cmd.Flags().GetString("cwd"); err == nil && dir != "" {
+			pt.Dir(dir)
+		}
+
+		if cleanEnv, err := cmd.Flags().GetBool("clean-env"); err == nil && cleanEnv {
+			allow, _ := cmd.Flags().GetStringSlice("env-allow")
+			pt.CleanEnv(allow...)
+		}
+
+		fixtures, _ := cmd.Flags().GetString("fixtures")
+		if sandbox, err := cmd.Flags().GetBool("sandbox"); err == nil && (sandbox || fixtures != "") {
+			if fixtures != "" {
+				if info, err := os.Stat(fixtures); err != nil || !info.IsDir() {
+					return fmt.Errorf("the fixtures %q of the sandbox are not a directory", fixtures)
+				}
+			}
+
+			pt.Sandbox(fixtures)
+		}
+
+		if env, err := cmd.Flags().GetStringArray("env"); err == nil {
+			for _, entry := range env {
+				name, value, ok := strings.Cut(entry, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("invalid environment variable %q, expected NAME=VALUE", entry)
+				}
+
+				pt.Setenv(name, value)
+			}
+		}
+
+		if unset, err := cmd.Flags().GetStringArray("unset-env"); err == nil {
+			for _, name := range unset {
+				pt.Unsetenv(name)
+			}
+		}
+
+		// Optional: Take the output of long-lived or interactive commands
+		// once they are idle or a deadline passed, and terminate them
+		//
+		if idle, err := cmd.Flags().GetDuration("snapshot-idle"); err == nil && idle > 0 {
+			pt.SnapshotIdle(idle)
+		}
+
+		if deadline, err := cmd.Flags().GetDuration("snapshot-deadline"); err == nil && deadline > 0 {
+			pt.SnapshotDeadline(deadline)
+		}
+
+		// Optional: Provide the input of interactive commands with a script
+		// instead of the standard input
+		//
+		if scriptFile, err := cmd.Flags().GetString("script"); err == nil && scriptFile != "" {
+			data, err := readFile(scriptFile)
+			if err != nil {
+				return fmt.Errorf("failed to read script: %w", err)
+			}
+
+			script, err := ptexec.ParseScript(bytes.NewReader(data))
+			if err != nil {
+				return err
+			}
+
+			pt.Script(script)
+		}
+
+		// Optional: Capture the standard error separately from the pseudo
+		// terminal, so that it can be shown in a distinct style
+		//
+		separateStderr, _ := cmd.Flags().GetBool("separate-stderr")
+		rawWriteStderr, _ := cmd.Flags().GetString("raw-write-stderr")
+		if rawWriteStderr != "" && !separateStderr {
+			return fmt.Errorf("the --raw-write-stderr flag requires --separate-stderr")
+		}
+
+		if separateStderr {
+			name, _ := cmd.Flags().GetString("stderr-style")
+			style, err := stderrStyle(name, selectedTheme)
+			if err != nil {
+				return err
+			}
+
+			pt.SeparateStderr(style)
+		}
+
+		// Optional: Run several commands in the same shell like in a terminal
+		// session, each command is shown with the prompt in front of its
+		// output, so there is no need to detect prompts in the output
+		//
+		if sessionFile != "" {
+			data, err := readFile(sessionFile)
+			if err != nil {
+				return fmt.Errorf("failed to read session: %w", err)
+			}
+
+			session, err := ptexec.ParseSession(bytes.NewReader(data))
+			if err != nil {
+				return err
+			}
+
+			pt.Session(session, func(command string) string {
+				return scaffold.CommandLine(command) + "\r\n"
+			})
+
+			scaffold.DisablePromptDetection(true)
+		}
+
 		// Initialise scaffold with a column sizing so that the
 		// content can be wrapped accordingly
 		//
@@ -137,6 +261,31 @@ window including all terminal colors and text decorations.
 			pt.Cols(uint16(columns))
 		}
 
+		// Use a fixed number of rows for the pseudo terminal, and optionally
+		// render exactly that many rows like a terminal window
+		//
+		rows, err := cmd.Flags().GetInt("rows")
+		if err != nil {
+			return err
+		}
+
+		if rows > 0 {
+			pt.Rows(uint16(rows))
+		}
+
+		viewport, err := cmd.Flags().GetBool("viewport")
+		if err != nil {
+			return err
+		}
+
+		if viewport {
+			if rows <= 0 {
+				return fmt.Errorf("the --viewport flag requires a fixed number of rows using --rows")
+			}
+
+			scaffold.SetRows(rows)
+		}
+
 		// Disable window shadow if requested
 		//
 		if val, err := cmd.Flags().GetBool("no-shadow"); err == nil {
@@ -155,24 +304,129 @@ window including all terminal colors and text decorations.
 			scaffold.ClipCanvas(val)
 		}
 
-		// Optional: Prepend command line arguments to output content
+		// Indicator for failed commands, which can be a red prompt and/or a
+		// badge with the exit status below the output
 		//
-		if includeCommand, err := cmd.Flags().GetBool("show-cmd"); err == nil && includeCommand && rawRead == "" {
-			if err := scaffold.AddCommand(args...); err != nil {
-				return err
+		exitIndicator, _ := cmd.Flags().GetString("exit-indicator")
+		switch exitIndicator {
+		case "none", "prompt", "badge", "both":
+		default:
+			return fmt.Errorf("unsupported exit indicator %q, supported exit indicators are none, prompt, badge, and both", exitIndicator)
+		}
+
+		// Determine output filename, the extension selects the format
+		//
+		filename, err := cmd.Flags().GetString("filename")
+		if filename == "" || err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read filename from command-line, defaulting to out.png")
+			filename = "out.png"
+		}
+
+		// Optional: Record the output with timestamps for formats that
+		// require timing information (animations, cast files)
+		//
+		var recording *ptexec.Recording
+		if isTimed(filename) {
+			if rawRead != "" && !isCast(rawRead) {
+				return fmt.Errorf("failed to create %s file: raw input of %q has no timing information, use a command or a cast file instead", filepath.Ext(filename), rawRead)
 			}
+
+			recording = ptexec.NewRecording()
+			pt.Record(recording)
 		}
 
+		// Terminal size of the captured output, which is the size of the
+		// pseudo terminal or the size stored in a cast file
+		//
+		var screenColumns, screenRows int
+
+		// Result of the command, and the error of a command that did not
+		// finish or failed, which is returned after the screenshot of the
+		// output captured until then is created
+		//
+		var result *ptexec.Result
+		var failed bool
+		var commandErr error
+
 		// Get the actual content for the screenshot
 		//
 		if rawRead == "" {
 			// Run the provided command in a pseudo terminal and capture
 			// the output to be later rendered into the screenshot
-			bytes, err := pt.Command(args[0], args[1:]...).Run()
-			if err != nil {
+			ctx := cmd.Context()
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			if sessionFile == "" {
+				pt.Command(args[0], args[1:]...)
+			}
+
+			result, err = pt.RunContext(ctx)
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				commandErr = fmt.Errorf("command did not finish within %v, the screenshot shows the output until then: %w", timeout, err)
+
+			case err != nil:
 				return fmt.Errorf("failed to run command in pseudo terminal: %w", err)
 			}
-			buf.Write(bytes)
+
+			// With a separate standard error, the raw output only contains
+			// the standard output, and the standard error is written as-is
+			switch {
+			case separateStderr && rawWrite != "":
+				buf.Write(result.Stdout)
+
+			default:
+				buf.Write(result.Output)
+			}
+
+			if rawWriteStderr != "" {
+				if err := writeFile(rawWriteStderr, result.Stderr); err != nil {
+					return err
+				}
+			}
+
+			// Commands that were terminated on purpose, e.g. after a snapshot,
+			// are not considered to be failed
+			failed = !result.Terminated && !result.Success()
+			if failed {
+				scaffold.SetCommandFailed(exitIndicator == "prompt" || exitIndicator == "both")
+
+				if failOnError, _ := cmd.Flags().GetBool("fail-on-error"); failOnError {
+					commandErr = fmt.Errorf("command failed: %w", errors.New(result.Status()))
+				}
+			}
+
+			cols, rows := pt.Size()
+			screenColumns, screenRows = int(cols), int(rows)
+
+		} else if isCast(rawRead) {
+			// Read the recorded output from an asciinema cast file,
+			// which is rendered at the given point in time
+			file, err := os.Open(filepath.Clean(rawRead))
+			if err != nil {
+				return fmt.Errorf("failed to read contents: %w", err)
+			}
+
+			defer func() { _ = file.Close() }()
+
+			castRecording, _, err := ptexec.ReadCast(file)
+			if err != nil {
+				return fmt.Errorf("failed to read contents: %w", err)
+			}
+
+			castTime, _ := cmd.Flags().GetDuration("cast-time")
+			buf.Write(castRecording.Output(castTime))
+
+			// The screen has the size of the terminal at that point in
+			// time, which differs from the header if it was resized
+			recording = castRecording
+			cols, rows := castRecording.SizeAt(castTime)
+			screenColumns, screenRows = int(cols), int(rows)
 
 		} else {
 			// Read the content from an existing file instead of
@@ -184,6 +438,17 @@ window including all terminal colors and text decorations.
 			buf.Write(bytes)
 		}
 
+		// Optional: Prepend command line arguments to output content, with
+		// typing enabled animations type the command in their own frames
+		//
+		typing, _ := cmd.Flags().GetBool("typing")
+		typing = typing && rawRead == "" && sessionFile == ""
+		if includeCommand, err := cmd.Flags().GetBool("show-cmd"); err == nil && (includeCommand || typing) && rawRead == "" && sessionFile == "" && !(typing && isAnimated(filename)) {
+			if err := scaffold.AddCommand(args...); err != nil {
+				return err
+			}
+		}
+
 		// Allow manual override of command output content
 		//
 		if edit, err := cmd.Flags().GetBool("edit"); err == nil && edit && rawRead == "" {
@@ -216,27 +481,53 @@ window including all terminal colors and text decorations.
 			buf.Write(bytes)
 		}
 
-		// Use improved ANSI parser if enabled
-		improvedANSI, _ := cmd.Flags().GetBool("improved-ansi")
-		if improvedANSI && rawRead != "" {
-			// Only use improved parser for raw-read files, not for live commands
-			// Use a large default to avoid unwanted wrapping, unless --columns is explicitly set
-			columns := 500 // Large default to preserve line lengths
+		// Use improved ANSI parser if enabled, the output is processed by a
+		// virtual terminal with the size of the terminal it was captured in
+		// Cast files are always recorded from a terminal and therefore
+		// processed with the parser using the size of the recording
+		var emulation *screen
+		if improvedANSI, _ := cmd.Flags().GetBool("improved-ansi"); improvedANSI || isCast(rawRead) {
+			// Use a large default to avoid unwanted wrapping in case the size
+			// is unknown, unless --columns is explicitly set
+			buffer, err := screenBuffer(cmd.Flags().GetString("screen-buffer"))
+			if err != nil {
+				return err
+			}
+
+			emulation = &screen{columns: 500, rows: screenRows, buffer: buffer, scrollback: !viewport}
+			if screenColumns > 0 {
+				emulation.columns = screenColumns
+			}
 			if explicitCols, err := cmd.Flags().GetInt("columns"); err == nil && explicitCols > 0 {
-				columns = explicitCols
+				emulation.columns = explicitCols
+			}
+			if rows > 0 {
+				emulation.rows = rows
 			}
-			vt := ansi.NewVirtualTerminal(columns)
-			parsed, err := vt.Parse(&buf)
+
+			parsed, err := emulation.render(buf.Bytes())
 			if err != nil {
 				// If parsing fails, continue with original content
 				fmt.Fprintf(os.Stderr, "Warning: ANSI parsing failed, using original content: %v\n", err)
+				emulation = nil
 			} else {
 				// Replace buffer content with parsed output
 				buf.Reset()
-				buf.WriteString(parsed.String())
+				buf.Write(parsed)
 			}
 		}
 
+		// Optional: Show the exit status of failed commands below the output
+		//
+		var badge string
+		if failed && (exitIndicator == "badge" || exitIndicator == "both") {
+			badge = exitBadge(result.Status())
+
+			output := bytes.TrimRight(buf.Bytes(), "\r\n")
+			buf.Truncate(len(output))
+			buf.WriteString(badge)
+		}
+
 		//
 		if rawWrite != "" {
 			// For raw-write, temporarily disable column wrapping
@@ -262,9 +553,18 @@ window including all terminal colors and text decorations.
 				defer func() { _ = output.Close() }()
 			}
 
-			return scaffold.WriteRaw(output)
+			if err := scaffold.WriteRaw(output); err != nil {
+				return err
+			}
+
+			return commandErr
 		}
 
+		// Keep the scaffold without content, animation frames are rendered
+		// each with their own content
+		//
+		var base = scaffold
+
 		// Add the captured output to the scaffold
 		//
 		if err := scaffold.AddContent(&buf); err != nil {
@@ -274,19 +574,75 @@ window including all terminal colors and text decorations.
 		// Optional: Save image to clipboard
 		//
 		if toClipboard, err := cmd.Flags().GetBool("clipboard"); err == nil && toClipboard {
-			return saveToClipboard(scaffold)
+			if err := saveToClipboard(scaffold); err != nil {
+				return err
+			}
+
+			return commandErr
 		}
 
-		// Save image to file
+		// Select the output format based on the file extension
 		//
-		filename, err := cmd.Flags().GetString("filename")
-		if filename == "" || err != nil {
-			fmt.Fprintf(os.Stderr, "failed to read filename from command-line, defaulting to out.png")
-			filename = "out.png"
-		}
+		var write func(io.Writer) error
+		switch extension := filepath.Ext(filename); extension {
+		case ".png":
+			write = scaffold.WritePNG
+
+		case ".svg":
+			write = scaffold.WriteSVG
+
+		case ".html", ".htm":
+			write = scaffold.WriteHTML
+
+		case ".pdf":
+			write = scaffold.WritePDF
+
+		case ".gif", ".apng", ".webp":
+			fps, _ := cmd.Flags().GetFloat64("fps")
+			maxIdle, _ := cmd.Flags().GetDuration("max-idle")
+			frames := animationFrames(recording, fps, maxIdle, emulation)
+			if badge != "" {
+				last := &frames[len(frames)-1]
+				last.Content = append(bytes.TrimRight(last.Content, "\r\n"), badge...)
+			}
+
+			// The command is typed first, and stays above the output,
+			// which appears with its original timing
+			if typing {
+				delay, _ := cmd.Flags().GetDuration("typing-delay")
+				jitter, _ := cmd.Flags().GetDuration("typing-jitter")
+				for i := range frames {
+					frames[i].Command = base.CommandLine(args...)
+				}
+
+				frames = append(base.TypingFrames(strings.Join(args, " "), delay, jitter), frames...)
+			}
+
+			switch extension {
+			case ".gif":
+				write = func(w io.Writer) error { return base.WriteGIF(w, frames) }
+
+			case ".apng":
+				write = func(w io.Writer) error { return base.WriteAPNG(w, frames) }
+
+			case ".webp":
+				write = func(w io.Writer) error { return base.WriteWebP(w, frames) }
+			}
+
+		case ".cast":
+			header := ptexec.CastHeader{
+				Env:   map[string]string{"SHELL": os.Getenv("SHELL"), "TERM": os.Getenv("TERM")},
+				Theme: castTheme(selectedTheme),
+			}
+
+			if len(args) > 0 {
+				header.Command = ptexec.CommandLine(args[0], args[1:]...)
+			}
 
-		if extension := filepath.Ext(filename); extension != ".png" {
-			return fmt.Errorf("file extension %q of filename %q is not supported, only png is supported", extension, filename)
+			write = func(w io.Writer) error { return recording.WriteCast(w, header) }
+
+		default:
+			return fmt.Errorf("file extension %q of filename %q is not supported, supported extensions are png, svg, html, pdf, gif, apng, webp, and cast", extension, filename)
 		}
 
 		file, err := os.Create(filepath.Clean(filename))
@@ -295,7 +651,11 @@ window including all terminal colors and text decorations.
 		}
 
 		defer func() { _ = file.Close() }()
-		return scaffold.WritePNG(file)
+		if err := write(file); err != nil {
+			return err
+		}
+
+		return commandErr
 	},
 }
 
@@ -356,6 +716,165 @@ func readFile(name string) ([]byte, error) {
 	}
 }
 
+// writeFile writes the data to the file, or to the standard error in case
+// the name is "-"
+func writeFile(name string, data []byte) error {
+	switch name {
+	case "-":
+		_, err := os.Stderr.Write(data)
+		return err
+
+	default:
+		if err := os.WriteFile(filepath.Clean(name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func isTimed(filename string) bool {
+	return isAnimated(filename) || filepath.Ext(filename) == ".cast"
+}
+
+func isAnimated(filename string) bool {
+	switch filepath.Ext(filename) {
+	case ".gif", ".apng", ".webp":
+		return true
+
+	default:
+		return false
+	}
+}
+
+func isCast(filename string) bool {
+	return filepath.Ext(filename) == ".cast"
+}
+
+func castTheme(t theme.Theme) *ptexec.CastTheme {
+	// cast files only support plain colors without alpha channel
+	var rgb = func(hex string) string {
+		if c, err := theme.ParseColor(hex); err == nil {
+			r, g, b, _ := c.RGBA()
+			return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+		}
+
+		return "#000000"
+	}
+
+	var palette []string
+	for _, hex := range []string{
+		t.Black, t.Red, t.Green, t.Yellow, t.Blue, t.Magenta, t.Cyan, t.White,
+		t.BrightBlack, t.BrightRed, t.BrightGreen, t.BrightYellow, t.BrightBlue, t.BrightMagenta, t.BrightCyan, t.BrightWhite,
+	} {
+		palette = append(palette, rgb(hex))
+	}
+
+	return &ptexec.CastTheme{
+		Foreground: rgb(t.Foreground),
+		Background: rgb(t.Background),
+		Palette:    strings.Join(palette, ":"),
+	}
+}
+
+// exitBadge returns the exit status of a command as a badge in its own line,
+// the padding uses non-breaking spaces since trailing spaces are trimmed
+func exitBadge(status string) string {
+	return fmt.Sprintf("\n\n\x1b[1;97;41m\u00a0%s\u00a0\x1b[0m", status)
+}
+
+// screen is the size of the virtual terminal used to process output, and
+// the screen buffer that is rendered
+type screen struct {
+	columns    int
+	rows       int
+	buffer     ansi.ScreenBuffer
+	scrollback bool
+}
+
+// render processes the output with a virtual terminal and returns the
+// resulting screen content with SGR escape sequences for the colors
+func (s *screen) render(data []byte) ([]byte, error) {
+	parsed, err := ansi.NewVirtualTerminal(s.columns, s.rows).Show(s.buffer).Scrollback(s.scrollback).Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(ansi.Render(*parsed)), nil
+}
+
+// stderrStyle returns how the separately captured standard error is shown,
+// using the standard error color of the theme, or its red color
+func stderrStyle(name string, t theme.Theme) (ptexec.StderrStyle, error) {
+	var hex = t.Stderr
+	if hex == "" {
+		hex = t.Red
+	}
+
+	var color = "\x1b[31m"
+	if c, err := theme.ParseColor(hex); err == nil {
+		r, g, b, _ := c.RGBA()
+		color = fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+	}
+
+	var gutter = color + "│\x1b[0m "
+
+	switch name {
+	case "color":
+		return ptexec.StderrStyle{Color: color}, nil
+
+	case "gutter":
+		return ptexec.StderrStyle{Gutter: gutter}, nil
+
+	case "both":
+		return ptexec.StderrStyle{Color: color, Gutter: gutter}, nil
+
+	default:
+		return ptexec.StderrStyle{}, fmt.Errorf("unsupported standard error style %q, supported styles are color, gutter, and both", name)
+	}
+}
+
+func screenBuffer(name string, err error) (ansi.ScreenBuffer, error) {
+	if err != nil {
+		return ansi.ActiveBuffer, err
+	}
+
+	switch name {
+	case "active":
+		return ansi.ActiveBuffer, nil
+
+	case "primary":
+		return ansi.PrimaryBuffer, nil
+
+	case "alternate":
+		return ansi.AlternateBuffer, nil
+
+	default:
+		return ansi.ActiveBuffer, fmt.Errorf("unsupported screen buffer %q, supported screen buffers are active, primary, and alternate", name)
+	}
+}
+
+func animationFrames(recording *ptexec.Recording, fps float64, maxIdle time.Duration, emulation *screen) []img.Frame {
+	var frames []img.Frame
+	for _, frame := range recording.Frames(fps, maxIdle) {
+		content := frame.Data
+		if emulation != nil {
+			if parsed, err := emulation.render(content); err == nil {
+				content = parsed
+			}
+		}
+
+		frames = append(frames, img.Frame{Content: content, Delay: frame.Delay})
+	}
+
+	// Commands without any output still result in one (empty) frame
+	if len(frames) == 0 {
+		frames = append(frames, img.Frame{Delay: time.Second})
+	}
+
+	return frames
+}
+
 func init() {
 	rootCmd.Flags().SortFlags = false
 
@@ -364,7 +883,13 @@ func init() {
 
 	// flags to control look
 	rootCmd.Flags().BoolP("show-cmd", "c", false, "include command in screenshot")
+	rootCmd.Flags().Bool("typing", false, "type the command character by character before its output appears in animated formats (gif, apng, webp), other formats show the command like --show-cmd")
+	rootCmd.Flags().Duration("typing-delay", 80*time.Millisecond, "average delay between two typed characters with --typing")
+	rootCmd.Flags().Duration("typing-jitter", 40*time.Millisecond, "maximum random deviation from the typing delay, so that typing looks natural")
+	rootCmd.Flags().String("exit-indicator", "none", "indicate failed commands: none, prompt (red prompt with --show-cmd), badge (exit status below the output), or both")
 	rootCmd.Flags().IntP("columns", "C", 0, "force fixed number of columns in screenshot")
+	rootCmd.Flags().Int("rows", 0, "force fixed number of rows of the terminal the command runs in")
+	rootCmd.Flags().Bool("viewport", false, "render exactly --rows rows like a terminal window, showing the screen or the last lines of the output")
 	rootCmd.Flags().Bool("no-decoration", false, "do not draw window decorations")
 	rootCmd.Flags().Bool("no-shadow", false, "do not draw window shadow")
 	rootCmd.Flags().BoolP("clip-canvas", "s", false, "clip canvas to visible image area (no margin)")
@@ -374,23 +899,47 @@ func init() {
 	rootCmd.Flags().String("shell-config", "", "shell configuration file to source (e.g., ~/.zshrc)")
 	rootCmd.Flags().StringSlice("shell-opts", []string{}, "additional shell options")
 
+	// flags for the command environment
+	rootCmd.Flags().String("cwd", "", "working directory of the command")
+	rootCmd.Flags().StringArray("env", []string{}, "set an environment variable of the command (NAME=VALUE), can be used multiple times")
+	rootCmd.Flags().StringArray("unset-env", []string{}, "remove an environment variable of the command, can be used multiple times")
+	rootCmd.Flags().Bool("clean-env", false, "do not inherit the environment except PATH and locale settings, and use fixed values for TERM, COLORTERM, HOME, and USER (COLUMNS and LINES only when the size is set with --columns or --rows, or inherited from the current terminal)")
+	rootCmd.Flags().StringSlice("env-allow", []string{}, "additional environment variables to keep with --clean-env")
+	rootCmd.Flags().Bool("sandbox", false, "run the command in a throwaway home and working directory with a fixed path, a clean environment, and fixed user name, host name, time zone, locale, and file times (the owner of files and the current time are not pinned)")
+	rootCmd.Flags().String("fixtures", "", "directory that is copied into the sandbox before the command runs (implies --sandbox)")
+	rootCmd.Flags().Bool("separate-stderr", false, "capture the standard error through a separate pipe instead of the pseudo terminal, and show it in a distinct style")
+	rootCmd.Flags().String("stderr-style", "color", "style of the separately captured standard error: color (stderr color of the theme), gutter (marker in front of each line), or both")
+
+	// flags for long-lived or interactive commands
+	rootCmd.Flags().Duration("snapshot-idle", 0, "take the screenshot once the command did not write output for this duration, and terminate it")
+	rootCmd.Flags().Duration("snapshot-deadline", 0, "take the screenshot after this duration at the latest, and terminate the command")
+	rootCmd.Flags().Bool("fail-on-error", false, "exit with an error after creating the screenshot in case the command failed")
+	rootCmd.Flags().Duration("timeout", 0, "terminate the command after this duration, and create the screenshot of the output until then with an error")
+	rootCmd.Flags().String("session", "", "run the commands of a file one after another in the same shell, each shown with the prompt like in a terminal session")
+	rootCmd.Flags().String("script", "", "script with input steps (send, key, wait, sleep, snapshot) used instead of the standard input")
+
 	// flags for theming
 	rootCmd.Flags().String("theme", "default", "color theme to use (default, catppuccin-mocha, nord, dracula, tokyo-night, gruvbox-dark, solarized-dark)")
-	rootCmd.Flags().String("theme-file", "", "path to custom theme JSON file")
+	rootCmd.Flags().String("theme-file", "", "path to custom theme JSON file, or the color scheme of iTerm2, Windows Terminal, Alacritty, kitty, Ghostty, Xresources, or base16/base24")
 
 	// flags for prompt customization
 	rootCmd.Flags().String("prompt", "", "custom prompt string (overrides default)")
 	rootCmd.Flags().Bool("syntax-highlight", false, "enable syntax highlighting for command")
 
 	// flags for output related settings
-	rootCmd.Flags().StringP("filename", "f", "out.png", "filename of the screenshot")
+	rootCmd.Flags().StringP("filename", "f", "out.png", "filename of the screenshot, the extension selects the format (png, svg, html, pdf, gif, apng, webp, cast)")
+	rootCmd.Flags().Float64("fps", 10, "frames per second of animated formats (gif, apng, webp)")
+	rootCmd.Flags().Duration("max-idle", time.Second, "maximum pause between two frames of animated formats, longer pauses are shortened")
 
 	// flags for raw output processing
 	rootCmd.Flags().String("raw-write", "", "write raw output to file instead of creating a screenshot")
-	rootCmd.Flags().String("raw-read", "", "read raw input from file instead of executing a command")
+	rootCmd.Flags().String("raw-write-stderr", "", "write the separately captured standard error as-is to file (requires --separate-stderr)")
+	rootCmd.Flags().String("raw-read", "", "read raw input from file instead of executing a command, .cast files are read as asciinema recordings")
+	rootCmd.Flags().Duration("cast-time", 0, "render the screen at this point in time of a cast file used with --raw-read (default is the final screen)")
 
 	// flags for cursor handling
-	rootCmd.Flags().Bool("improved-ansi", false, "use improved ANSI parser with cursor handling (only for --raw-read)")
+	rootCmd.Flags().Bool("improved-ansi", true, "process output with a virtual terminal of the same size as the pseudo terminal (use --improved-ansi=false for raw output)")
+	rootCmd.Flags().String("screen-buffer", "active", "screen buffer to render: active (shown at the end), primary (normal screen with scrollback), or alternate (last frame of full-screen programs)")
 	rootCmd.Flags().Bool("no-prompt-detect", false, "disable automatic prompt detection and command highlighting")
 
 	// internals
@@ -0,0 +1,59 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/gonvenience/bunt"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/termshot/internal/theme"
+)
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "Work with theme files",
+}
+
+var themeValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Checks a theme file and reports all problems",
+	Long: `Loads the theme file like --theme-file does and reports all problems that
+were found, like syntax errors, unknown fields, invalid colors, or missing
+colors, with their line and column in the file.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loaded, err := theme.LoadThemeFromFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		// #nosec G104
+		// nolint:all
+		bunt.Printf("Lime{✓} %s is a valid theme DimGray{(%s)}\n", args[0], loaded.Name)
+		return nil
+	},
+}
+
+func init() {
+	themeCmd.AddCommand(themeValidateCmd)
+	rootCmd.AddCommand(themeCmd)
+}
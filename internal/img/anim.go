@@ -0,0 +1,408 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gonvenience/bunt"
+)
+
+// typingPause is how long the empty prompt is shown before typing starts,
+// and how long the typed command is shown before it is run
+const typingPause = 500 * time.Millisecond
+
+// Frame is a single state of an animation, the content is added to a copy of
+// the scaffold and the resulting image is shown for the given delay
+type Frame struct {
+	// Command is an optional command line that is added before the content,
+	// like a command added with AddCommand
+	Command string
+
+	Content []byte
+	Delay   time.Duration
+}
+
+// TypingFrames returns the frames of the command being typed character by
+// character after the prompt, each character takes the delay plus or minus a
+// random jitter, the random numbers use a fixed seed so that the same command
+// always results in the same animation
+func (s *Scaffold) TypingFrames(command string, delay time.Duration, jitter time.Duration) []Frame {
+	var random = rand.New(rand.NewPCG(uint64(len(command)), uint64(delay)))
+	var runes = []rune(command)
+
+	var frames = make([]Frame, 0, len(runes)+1)
+	for i := range len(runes) + 1 {
+		var frameDelay = delay
+		if jitter > 0 {
+			frameDelay += time.Duration(random.Int64N(int64(2*jitter)+1)) - jitter
+		}
+
+		if i == 0 || i == len(runes) {
+			frameDelay = typingPause
+		}
+
+		frames = append(frames, Frame{
+			Command: s.CommandLine(string(runes[:i])),
+			Delay:   max(frameDelay, 0),
+		})
+	}
+
+	return frames
+}
+
+// WriteGIF writes the frames as an animated GIF into the provided writer
+func (s *Scaffold) WriteGIF(w io.Writer, frames []Frame) error {
+	images, err := s.frameImages(frames)
+	if err != nil {
+		return err
+	}
+
+	var anim gif.GIF
+	for i, img := range images {
+		anim.Image = append(anim.Image, paletted(img))
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+
+		// GIF delays are in 100ths of a second, and most viewers do not
+		// support delays shorter than 20ms
+		anim.Delay = append(anim.Delay, max(2, int(frames[i].Delay/(10*time.Millisecond))))
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// WriteAPNG writes the frames as an animated PNG into the provided writer
+func (s *Scaffold) WriteAPNG(w io.Writer, frames []Frame) error {
+	images, err := s.frameImages(frames)
+	if err != nil {
+		return err
+	}
+
+	if len(images) == 0 {
+		return fmt.Errorf("no frames to write")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+
+	bounds := images[0].Bounds()
+	writeChunk(&buf, "IHDR", uint32(bounds.Dx()), uint32(bounds.Dy()), []byte{8, 6, 0, 0, 0})
+	writeChunk(&buf, "acTL", uint32(len(images)), uint32(0))
+
+	var sequence uint32
+	for i, img := range images {
+		data, err := pngImageData(img)
+		if err != nil {
+			return err
+		}
+
+		delay := uint16(min(frames[i].Delay/time.Millisecond, math.MaxUint16))
+		writeChunk(&buf, "fcTL", sequence, uint32(bounds.Dx()), uint32(bounds.Dy()), uint32(0), uint32(0), delay, uint16(1000), []byte{0, 0})
+		sequence++
+
+		switch i {
+		case 0:
+			writeChunk(&buf, "IDAT", data)
+
+		default:
+			writeChunk(&buf, "fdAT", sequence, data)
+			sequence++
+		}
+	}
+
+	writeChunk(&buf, "IEND")
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// frameImages renders all frames into images of the same size, the content
+// of each frame is padded to the largest frame so that the window does not
+// change its size during the animation
+func (s *Scaffold) frameImages(frames []Frame) ([]*image.RGBA, error) {
+	var scaffolds = make([]Scaffold, len(frames))
+	var lines, columns int
+	for i, frame := range frames {
+		tmp := *s
+		tmp.content = append(bunt.String{}, s.content...)
+		if frame.Command != "" {
+			if err := tmp.AddContent(strings.NewReader(frame.Command + "\n")); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := tmp.AddContent(bytes.NewReader(frame.Content)); err != nil {
+			return nil, err
+		}
+
+		for _, line := range splitLines(tmp.content) {
+			columns = max(columns, len(line))
+		}
+
+		lines = max(lines, s.rows, len(splitLines(tmp.content)))
+		scaffolds[i] = tmp
+	}
+
+	var images = make([]image.Image, len(scaffolds))
+	var width, height int
+	for i := range scaffolds {
+		scaffolds[i].content = pad(scaffolds[i].content, lines, columns)
+
+		img, err := scaffolds[i].image()
+		if err != nil {
+			return nil, err
+		}
+
+		images[i] = scaffolds[i].clip(img)
+		width = max(width, images[i].Bounds().Dx())
+		height = max(height, images[i].Bounds().Dy())
+	}
+
+	var result = make([]*image.RGBA, len(images))
+	for i, img := range images {
+		result[i] = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(result[i], img.Bounds().Sub(img.Bounds().Min), img, img.Bounds().Min, draw.Src)
+	}
+
+	return result, nil
+}
+
+// splitLines splits the content into lines, ignoring a trailing newline
+func splitLines(content bunt.String) []bunt.String {
+	if len(content) > 0 && content[len(content)-1].Symbol == '\n' {
+		content = content[:len(content)-1]
+	}
+
+	var result []bunt.String
+	var start int
+	for i, cr := range content {
+		if cr.Symbol == '\n' {
+			result = append(result, content[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(result, content[start:])
+}
+
+// pad fills the content up with spaces and empty lines to the given size
+func pad(content bunt.String, lines int, columns int) bunt.String {
+	var result bunt.String
+	var split = splitLines(content)
+	for i := 0; i < lines; i++ {
+		var line bunt.String
+		if i < len(split) {
+			line = split[i]
+		}
+
+		result = append(result, line...)
+		for j := len(line); j < columns; j++ {
+			result = append(result, bunt.ColoredRune{Symbol: ' '})
+		}
+
+		if i < lines-1 {
+			result = append(result, bunt.ColoredRune{Symbol: '\n'})
+		}
+	}
+
+	return result
+}
+
+// paletted converts the image into a paletted image using the most frequent
+// colors, the first palette entry is reserved for transparent pixels since
+// GIF does not support partial transparency
+func paletted(img *image.RGBA) *image.Paletted {
+	var opaque = func(x, y int) (color.RGBA, bool) {
+		c := img.RGBAAt(x, y)
+		if c.A < 0x80 {
+			return color.RGBA{}, false
+		}
+
+		return color.RGBA{
+			R: uint8(uint32(c.R) * 0xFF / uint32(c.A)),
+			G: uint8(uint32(c.G) * 0xFF / uint32(c.A)),
+			B: uint8(uint32(c.B) * 0xFF / uint32(c.A)),
+			A: 0xFF,
+		}, true
+	}
+
+	var bounds = img.Bounds()
+	var counts = map[color.RGBA]int{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if c, ok := opaque(x, y); ok {
+				counts[c]++
+			}
+		}
+	}
+
+	var colors = make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+
+	sort.Slice(colors, func(i, j int) bool {
+		if counts[colors[i]] != counts[colors[j]] {
+			return counts[colors[i]] > counts[colors[j]]
+		}
+
+		a, b := colors[i], colors[j]
+		return uint32(a.R)<<16|uint32(a.G)<<8|uint32(a.B) < uint32(b.R)<<16|uint32(b.G)<<8|uint32(b.B)
+	})
+
+	var palette = color.Palette{color.RGBA{}}
+	for i := 0; i < len(colors) && len(palette) < 256; i++ {
+		palette = append(palette, colors[i])
+	}
+
+	var result = image.NewPaletted(bounds, palette)
+	var cache = map[color.RGBA]uint8{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c, ok := opaque(x, y)
+			if !ok {
+				result.SetColorIndex(x, y, 0)
+				continue
+			}
+
+			idx, ok := cache[c]
+			if !ok {
+				idx = uint8(palette[1:].Index(c) + 1)
+				cache[c] = idx
+			}
+
+			result.SetColorIndex(x, y, idx)
+		}
+	}
+
+	return result
+}
+
+// pngImageData returns the compressed image data of the image in the PNG
+// RGBA format, each row uses the filter with the smallest sum of values
+func pngImageData(img *image.RGBA) ([]byte, error) {
+	var bounds = img.Bounds()
+	var nrgba = image.NewNRGBA(bounds)
+	draw.Draw(nrgba, bounds, img, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+
+	var stride = 4 * bounds.Dx()
+	var previous = make([]byte, stride)
+	var filtered = make([][]byte, 5)
+	for i := range filtered {
+		filtered[i] = make([]byte, stride+1)
+		filtered[i][0] = byte(i)
+	}
+
+	for y := 0; y < bounds.Dy(); y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+stride]
+		for x := 0; x < stride; x++ {
+			var left, upLeft byte
+			if x >= 4 {
+				left, upLeft = row[x-4], previous[x-4]
+			}
+
+			up := previous[x]
+			filtered[0][x+1] = row[x]
+			filtered[1][x+1] = row[x] - left
+			filtered[2][x+1] = row[x] - up
+			filtered[3][x+1] = row[x] - byte((int(left)+int(up))/2)
+			filtered[4][x+1] = row[x] - paeth(left, up, upLeft)
+		}
+
+		best, bestSum := 0, math.MaxInt
+		for i, candidate := range filtered {
+			var sum int
+			for _, b := range candidate[1:] {
+				sum += int(math.Abs(float64(int8(b))))
+			}
+
+			if sum < bestSum {
+				best, bestSum = i, sum
+			}
+		}
+
+		if _, err := zw.Write(filtered[best]); err != nil {
+			return nil, err
+		}
+
+		copy(previous, row)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// paeth is the Paeth predictor as defined in the PNG specification
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := math.Abs(float64(p-int(a))), math.Abs(float64(p-int(b))), math.Abs(float64(p-int(c)))
+
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+
+	case pb <= pc:
+		return b
+
+	default:
+		return c
+	}
+}
+
+// writeChunk writes a PNG chunk with the given fields as the chunk data
+func writeChunk(w *bytes.Buffer, name string, fields ...interface{}) {
+	var data bytes.Buffer
+	for _, field := range fields {
+		switch typed := field.(type) {
+		case []byte:
+			data.Write(typed)
+
+		default:
+			_ = binary.Write(&data, binary.BigEndian, typed)
+		}
+	}
+
+	_ = binary.Write(w, binary.BigEndian, uint32(data.Len()))
+
+	crc := crc32.NewIEEE()
+	_, _ = io.MultiWriter(w, crc).Write(append([]byte(name), data.Bytes()...))
+	_ = binary.Write(w, binary.BigEndian, crc.Sum32())
+}
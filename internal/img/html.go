@@ -0,0 +1,193 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/homeport/termshot/internal/theme"
+)
+
+// WriteHTML writes the scaffold content as a self-contained HTML document
+// into the provided writer, the content is placed in a pre block so that it
+// can be selected and copied
+func (s *Scaffold) WriteHTML(w io.Writer) error {
+	// all sizes are based on the unscaled values of the PNG output
+	var px = func(value float64) string { return num(value) + "px" }
+
+	var margin = px(s.margin / s.factor)
+	if s.clipCanvas {
+		margin = "0"
+	}
+
+	var shadow = "none"
+	if s.drawShadow {
+		shadow = fmt.Sprintf("%s %s %s %s",
+			px(s.shadowOffsetX/s.factor),
+			px(s.shadowOffsetY/s.factor),
+			px(float64(s.shadowRadius)/s.factor),
+			cssColor(s.shadowBaseColor),
+		)
+	}
+
+	var width = "auto"
+	if s.columns != 0 {
+		width = fmt.Sprintf("%dch", s.columns)
+	}
+
+	var height = "auto"
+	if s.rows != 0 {
+		height = px(float64(s.rows) * s.fontSize() / s.factor * s.lineSpacing)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>termshot</title>\n<style>\n")
+	fmt.Fprintf(&buf, "body { margin: 0; padding: %s; }\n", margin)
+	fmt.Fprintf(&buf, ".window { display: inline-block; padding: %s; background: %s; border: 1px solid %s; border-radius: %s; box-shadow: %s; }\n",
+		px(s.padding/s.factor),
+		cssColor(s.currentTheme.Background),
+		cssColor(s.currentTheme.WindowBorder),
+		px(6),
+		shadow,
+	)
+	fmt.Fprintf(&buf, ".buttons { height: %s; }\n", px(40))
+	fmt.Fprintf(&buf, ".buttons span { display: inline-block; width: %s; height: %s; margin-right: %s; border-radius: 50%%; }\n", px(18), px(18), px(7))
+	fmt.Fprintf(&buf, "pre { margin: 0; width: %s; height: %s; font-family: %s; font-size: %s; line-height: %s; tab-size: %d; color: %s; }\n",
+		width,
+		height,
+		svgFontFamily,
+		px(s.fontSize()/s.factor),
+		num(s.lineSpacing),
+		s.tabSpaces,
+		hexColor(s.defaultForegroundColor),
+	)
+	buf.WriteString(".b { font-weight: bold; }\n.i { font-style: italic; }\n.u { text-decoration: underline; }\n")
+	buf.WriteString("</style>\n</head>\n<body>\n<div class=\"window\">\n")
+
+	// Optional: Draw window decorations (i.e. three buttons)
+	//
+	if s.drawDecorations {
+		buf.WriteString("<div class=\"buttons\">")
+		for _, color := range s.buttonColors() {
+			fmt.Fprintf(&buf, "<span style=\"background: %s\"></span>", cssColor(color))
+		}
+		buf.WriteString("</div>\n")
+	}
+
+	buf.WriteString("<pre>")
+
+	// Consecutive runes with the same settings are combined into one span,
+	// a trailing newline is omitted like it is in the image output
+	//
+	content := s.content
+	if len(content) > 0 && content[len(content)-1].Symbol == '\n' {
+		content = content[:len(content)-1]
+	}
+
+	for i := 0; i < len(content); {
+		j := i
+		for j < len(content) && content[j].Settings == content[i].Settings {
+			j++
+		}
+
+		text := html.EscapeString(runes(content[i:j]))
+		if style := s.htmlStyle(content[i]); style != "" {
+			fmt.Fprintf(&buf, "<span%s>%s</span>", style, text)
+		} else {
+			buf.WriteString(text)
+		}
+
+		i = j
+	}
+
+	buf.WriteString("</pre>\n</div>\n</body>\n</html>\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// htmlStyle returns the class and style attributes for the given rune, or an
+// empty string if the rune has no special settings
+func (s *Scaffold) htmlStyle(cr bunt.ColoredRune) string {
+	var classes []string
+	if cr.Settings&0x04 != 0 {
+		classes = append(classes, "b")
+	}
+
+	if cr.Settings&0x08 != 0 {
+		classes = append(classes, "i")
+	}
+
+	if cr.Settings&0x10 != 0 {
+		classes = append(classes, "u")
+	}
+
+	var styles []string
+	if cr.Settings&0x01 != 0 {
+		styles = append(styles, "color: "+hexColor(s.foregroundColor(cr)))
+	}
+
+	if bg, ok := s.backgroundColor(cr); ok {
+		styles = append(styles, "background: "+hexColor(bg))
+	}
+
+	var result string
+	if len(classes) > 0 {
+		result += fmt.Sprintf(" class=\"%s\"", strings.Join(classes, " "))
+	}
+
+	if len(styles) > 0 {
+		result += fmt.Sprintf(" style=\"%s\"", strings.Join(styles, "; "))
+	}
+
+	return result
+}
+
+// cssColor returns the CSS notation of the given theme color, which uses
+// the rgba function in case the color has an alpha channel
+func cssColor(hex string) string {
+	c, err := theme.ParseColor(hex)
+	if err != nil {
+		return "transparent"
+	}
+
+	r, g, b, a := c.RGBA()
+	if a>>8 != 0xFF {
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)", r>>8, g>>8, b>>8, num(float64(a>>8)/0xFF))
+	}
+
+	return hexColor(c)
+}
+
+// runes returns the plain text of the given colored runes
+func runes(str bunt.String) string {
+	var sb strings.Builder
+	for _, cr := range str {
+		sb.WriteRune(cr.Symbol)
+	}
+
+	return sb.String()
+}
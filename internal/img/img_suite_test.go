@@ -22,14 +22,20 @@ package img_test
 
 import (
 	"bytes"
+	"compress/zlib"
+	"encoding/binary"
 	"fmt"
+	"image"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
+	"golang.org/x/image/vp8l"
 
 	. "github.com/homeport/termshot/internal/img"
 )
@@ -43,6 +49,69 @@ func testdata(path ...string) string {
 	return filepath.Join(append([]string{"..", "..", "test", "data"}, path...)...)
 }
 
+// svgHeight returns the height attribute of the SVG root element
+func svgHeight(svg string) float64 {
+	var height float64
+	_, rest, _ := strings.Cut(svg, ` height="`)
+	_, _ = fmt.Sscanf(rest, "%g", &height)
+	return height
+}
+
+// pdfUnderlines returns the start and end x coordinates of the lines drawn
+// in the content streams of a PDF document, which are the underlines
+func pdfUnderlines(data []byte) [][2]float64 {
+	var result [][2]float64
+	for _, part := range bytes.Split(data, []byte("stream\n"))[1:] {
+		compressed, _, _ := bytes.Cut(part, []byte("\nendstream"))
+		zr, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			continue
+		}
+
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			var x1, y1, x2, y2 float64
+			if n, _ := fmt.Sscanf(line, "%g %g m %g %g l S", &x1, &y1, &x2, &y2); n == 4 {
+				result = append(result, [2]float64{x1, x2})
+			}
+		}
+	}
+
+	return result
+}
+
+// webpFrames returns the areas and images of the frames of an animated WebP
+func webpFrames(data []byte) ([]image.Rectangle, []image.Image, error) {
+	var uint24 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 | int(b[2])<<16 }
+
+	var rects []image.Rectangle
+	var images []image.Image
+	for data = data[12:]; len(data) >= 8; {
+		name, size := string(data[:4]), int(binary.LittleEndian.Uint32(data[4:8]))
+		chunk := data[8 : 8+size]
+		data = data[8+size+size%2:]
+		if name != "ANMF" {
+			continue
+		}
+
+		x, y := 2*uint24(chunk[0:]), 2*uint24(chunk[3:])
+		rects = append(rects, image.Rect(x, y, x+uint24(chunk[6:])+1, y+uint24(chunk[9:])+1))
+
+		img, err := vp8l.Decode(bytes.NewReader(chunk[24:]))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		images = append(images, img)
+	}
+
+	return rects, images, nil
+}
+
 func LookLike(path string) types.GomegaMatcher {
 	return &LookLikeMatcher{path}
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/font"
 	"github.com/gonvenience/term"
+	"github.com/homeport/termshot/internal/ansi"
 	"github.com/homeport/termshot/internal/highlight"
 	"github.com/homeport/termshot/internal/theme"
 	imgfont "golang.org/x/image/font"
@@ -61,6 +62,7 @@ type Scaffold struct {
 	factor float64
 
 	columns int
+	rows    int
 
 	defaultForegroundColor color.Color
 
@@ -88,7 +90,8 @@ type Scaffold struct {
 	currentTheme theme.Theme
 	
 	// Prompt customization
-	customPrompt string
+	customPrompt  string
+	commandFailed bool
 	
 	// Syntax highlighting
 	syntaxHighlight bool
@@ -149,6 +152,10 @@ func (s *Scaffold) SetColumns(columns int) { s.columns = columns }
 
 func (s *Scaffold) GetColumns() int { return s.columns }
 
+// SetRows configures a fixed number of rows like a terminal window, only the
+// last lines of the content are shown, and fewer lines are padded
+func (s *Scaffold) SetRows(rows int) { s.rows = rows }
+
 func (s *Scaffold) DrawDecorations(value bool) { s.drawDecorations = value }
 
 func (s *Scaffold) DrawShadow(value bool) { s.drawShadow = value }
@@ -167,6 +174,9 @@ func (s *Scaffold) SetTheme(t theme.Theme) {
 
 func (s *Scaffold) SetPrompt(prompt string) { s.customPrompt = prompt }
 
+// SetCommandFailed configures that the prompt of the command is shown in red
+func (s *Scaffold) SetCommandFailed(failed bool) { s.commandFailed = failed }
+
 func (s *Scaffold) EnableSyntaxHighlighting(enable bool) { s.syntaxHighlight = enable }
 
 func (s *Scaffold) DisablePromptDetection(disable bool) { s.noPromptDetect = disable }
@@ -181,6 +191,12 @@ func (s *Scaffold) GetFixedColumns() int {
 }
 
 func (s *Scaffold) AddCommand(args ...string) error {
+	return s.AddContent(strings.NewReader(s.CommandLine(args...) + "\n"))
+}
+
+// CommandLine returns the prompt and the command as shown by AddCommand,
+// without a trailing newline
+func (s *Scaffold) CommandLine(args ...string) string {
 	prompt := commandIndicator
 	if s.customPrompt != "" {
 		prompt = s.customPrompt
@@ -190,18 +206,30 @@ func (s *Scaffold) AddCommand(args ...string) error {
 	
 	// Apply syntax highlighting if enabled
 	if s.syntaxHighlight {
-		return s.AddContent(strings.NewReader(
-			s.syntaxHighlightCommand(prompt, cmdString) + "\n",
-		))
+		return s.syntaxHighlightCommand(prompt, cmdString)
 	}
 	
 	// Default behavior without syntax highlighting
-	return s.AddContent(strings.NewReader(
-		bunt.Sprintf("Lime{%s} DimGray{%s}\n",
-			prompt,
-			cmdString,
-		),
-	))
+	return s.promptMarkup(prompt) + bunt.Sprintf(" DimGray{%s}", cmdString)
+}
+
+// promptMarkup returns the prompt in green, or in red if the command failed
+func (s *Scaffold) promptMarkup(prompt string) string {
+	if s.commandFailed {
+		return paletteText(9, prompt)
+	}
+
+	return paletteText(10, prompt)
+}
+
+// paletteText returns the text in a color of the 256 color palette, so that
+// it is shown in the respective color of the theme
+func paletteText(index int, text string) string {
+	if !bunt.UseColors() {
+		return text
+	}
+
+	return fmt.Sprintf("\x1b[38;5;%dm%s\x1b[0m", index, text)
 }
 
 func (s *Scaffold) syntaxHighlightCommand(prompt string, command string) string {
@@ -209,7 +237,7 @@ func (s *Scaffold) syntaxHighlightCommand(prompt string, command string) string
 	tokens := lexer.Tokenize()
 	
 	var result strings.Builder
-	result.WriteString(bunt.Sprintf("Lime{%s} ", prompt))
+	result.WriteString(s.promptMarkup(prompt) + " ")
 	
 	for _, token := range tokens {
 		coloredText := s.colorizeToken(token)
@@ -222,23 +250,23 @@ func (s *Scaffold) syntaxHighlightCommand(prompt string, command string) string
 func (s *Scaffold) colorizeToken(token highlight.Token) string {
 	switch token.Type {
 	case highlight.TokenCommand:
-		return bunt.Sprintf("Cyan{%s}", token.Value)
+		return paletteText(14, token.Value)
 	case highlight.TokenKeyword:
-		return bunt.Sprintf("Magenta{%s}", token.Value)
+		return paletteText(13, token.Value)
 	case highlight.TokenFlag:
-		return bunt.Sprintf("Yellow{%s}", token.Value)
+		return paletteText(11, token.Value)
 	case highlight.TokenString:
-		return bunt.Sprintf("Green{%s}", token.Value)
+		return paletteText(2, token.Value)
 	case highlight.TokenVariable:
-		return bunt.Sprintf("Blue{%s}", token.Value)
+		return paletteText(12, token.Value)
 	case highlight.TokenOperator:
-		return bunt.Sprintf("Red{%s}", token.Value)
+		return paletteText(9, token.Value)
 	case highlight.TokenComment:
 		return bunt.Sprintf("DimGray{%s}", token.Value)
 	case highlight.TokenNumber:
-		return bunt.Sprintf("Magenta{%s}", token.Value)
+		return paletteText(13, token.Value)
 	case highlight.TokenPath:
-		return bunt.Sprintf("Cyan{%s}", token.Value)
+		return paletteText(14, token.Value)
 	default:
 		return token.Value
 	}
@@ -398,6 +426,14 @@ func (s *Scaffold) highlightCommandLine(line bunt.String) bunt.String {
 	promptSettings |= uint64(166) << 16
 	promptSettings |= uint64(247) << 24
 
+	// Red color for the prompt of a failed command
+	if s.commandFailed {
+		if redColor, err := theme.ParseColor(s.currentTheme.Red); err == nil {
+			r, g, b, _ := redColor.RGBA()
+			promptSettings = 1 | uint64(r>>8)<<8 | uint64(g>>8)<<16 | uint64(b>>8)<<24
+		}
+	}
+
 	// Green color for command
 	greenColor, _ := theme.ParseColor(s.currentTheme.Green)
 	if greenColor == nil {
@@ -454,75 +490,20 @@ func (s *Scaffold) highlightCommandLine(line bunt.String) bunt.String {
 	return result
 }
 
-// remapAnsiColor maps standard ANSI colors to theme colors
-// Only remaps the 16 standard ANSI colors, leaves true RGB colors unchanged
-func (s *Scaffold) remapAnsiColor(r, g, b int) color.Color {
-	// Standard ANSI 16 colors have very specific RGB values
-	// We should only remap these exact values, not arbitrary RGB colors
-	type ansiColor struct{ r, g, b int }
-	
-	// Map of exact standard ANSI color values to theme colors
-	// These are the default values used by most terminal emulators
-	standardColors := map[ansiColor]string{
-		// Normal colors (30-37) - typical default values
-		{0, 0, 0}:       s.currentTheme.Black,
-		{128, 0, 0}:     s.currentTheme.Red,
-		{0, 128, 0}:     s.currentTheme.Green,
-		{128, 128, 0}:   s.currentTheme.Yellow,
-		{0, 0, 128}:     s.currentTheme.Blue,
-		{128, 0, 128}:   s.currentTheme.Magenta,
-		{0, 128, 128}:   s.currentTheme.Cyan,
-		{192, 192, 192}: s.currentTheme.White,
-		
-		// Bright colors (90-97) - typical default values
-		{128, 128, 128}: s.currentTheme.BrightBlack,
-		{255, 0, 0}:     s.currentTheme.BrightRed,
-		{0, 255, 0}:     s.currentTheme.BrightGreen,
-		{255, 255, 0}:   s.currentTheme.BrightYellow,
-		{0, 0, 255}:     s.currentTheme.BrightBlue,
-		{255, 0, 255}:   s.currentTheme.BrightMagenta,
-		{0, 255, 255}:   s.currentTheme.BrightCyan,
-		{255, 255, 255}: s.currentTheme.BrightWhite,
-	}
-	
-	// Check for exact match with standard ANSI colors
-	if hexColor, ok := standardColors[ansiColor{r, g, b}]; ok {
-		if c, err := theme.ParseColor(hexColor); err == nil {
-			return c
-		}
-	}
-	
-	// Check with small tolerance (5) for slight variations in ANSI colors
-	tolerance := 5
-	for ansi, hexColor := range standardColors {
-		dr := r - ansi.r
-		dg := g - ansi.g
-		db := b - ansi.b
-		if dr < 0 {
-			dr = -dr
-		}
-		if dg < 0 {
-			dg = -dg
-		}
-		if db < 0 {
-			db = -db
-		}
-		
-		if dr <= tolerance && dg <= tolerance && db <= tolerance {
-			if c, err := theme.ParseColor(hexColor); err == nil {
-				return c
-			}
+// themeColor returns the color of the theme for colors of the 256 color
+// palette, colors set as RGB values are used as they are
+func (s *Scaffold) themeColor(c ansi.Color) color.Color {
+	if c.Index >= 0 {
+		if parsed, err := theme.ParseColor(s.currentTheme.Color(c.Index)); err == nil {
+			return parsed
 		}
 	}
-	
-	// Not a standard ANSI color - this is likely a true RGB color from the terminal
-	// Return it unchanged to preserve the terminal's actual color scheme
-	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
-}
 
+	return color.RGBA{R: c.RGB[0], G: c.RGB[1], B: c.RGB[2], A: 255}
+}
 
 func (s *Scaffold) AddContent(in io.Reader) error {
-	parsed, err := bunt.ParseStream(in)
+	parsed, err := ansi.ParseStream(in)
 	if err != nil {
 		return fmt.Errorf("failed to parse input stream: %w", err)
 	}
@@ -555,9 +536,56 @@ func (s *Scaffold) AddContent(in io.Reader) error {
 
 	s.content = append(s.content, tmp...)
 
+	// Only keep the lines that fit into a fixed number of rows
+	if s.rows > 0 {
+		s.content = lastLines(s.content, s.rows)
+	}
+
 	return nil
 }
 
+// lastLines returns the last lines of the content, a trailing newline does
+// not count as an additional line
+func lastLines(content bunt.String, n int) bunt.String {
+	end := len(content)
+	if end > 0 && content[end-1].Symbol == '\n' {
+		end--
+	}
+
+	var count int
+	for i := end - 1; i >= 0; i-- {
+		if content[i].Symbol == '\n' {
+			if count++; count == n {
+				return content[i+1:]
+			}
+		}
+	}
+
+	return content
+}
+
+// fontFace returns the font face matching the text emphasis of the rune
+func (s *Scaffold) fontFace(cr bunt.ColoredRune) imgfont.Face {
+	switch cr.Settings & 0x1C {
+	case 4:
+		return s.bold
+
+	case 8:
+		return s.italic
+
+	case 12:
+		return s.boldItalic
+
+	default:
+		return s.regular
+	}
+}
+
+// buttonColors returns the colors of the three window buttons
+func (s *Scaffold) buttonColors() []string {
+	return []string{s.currentTheme.WindowRed, s.currentTheme.WindowYellow, s.currentTheme.WindowGreen}
+}
+
 func (s *Scaffold) fontHeight() float64 {
 	return float64(s.regular.Metrics().Height >> 6)
 }
@@ -593,13 +621,27 @@ func (s *Scaffold) measureContent() (width float64, height float64) {
 		width = float64(tmpDrawer.MeasureString(strings.Repeat("a", s.columns)) >> 6)
 	}
 
-	// height, lines times font height and line spacing
-	height = float64(len(lines)) * s.fontHeight() * s.lineSpacing
+	// height, lines (or fixed rows) times font height and line spacing
+	height = float64(max(len(lines), s.rows)) * s.fontHeight() * s.lineSpacing
 
 	return width, height
 }
 
-func (s *Scaffold) image() (image.Image, error) {
+// frame describes the geometry of the lookalike terminal window, which is
+// shared between the different output formats
+type frame struct {
+	width, height float64
+
+	x, y                      float64
+	windowWidth, windowHeight float64
+
+	corner, radius, distance float64
+
+	paddingX, paddingY float64
+	titleOffset        float64
+}
+
+func (s *Scaffold) frame() frame {
 	var f = func(value float64) float64 { return s.factor * value }
 
 	var (
@@ -628,16 +670,131 @@ func (s *Scaffold) image() (image.Image, error) {
 	width := contentWidth + 2*marginX + 2*paddingX
 	height := contentHeight + 2*marginY + 2*paddingY + titleOffset
 
-	dc := gg.NewContext(int(width), int(height))
-
-	// Optional: Apply blurred rounded rectangle to mimic the window shadow
-	//
 	if s.drawShadow {
 		xOffset -= s.shadowOffsetX / 2
 		yOffset -= s.shadowOffsetY / 2
+	}
+
+	return frame{
+		width:        width,
+		height:       height,
+		x:            xOffset,
+		y:            yOffset,
+		windowWidth:  width - 2*marginX,
+		windowHeight: height - 2*marginY,
+		corner:       corner,
+		radius:       radius,
+		distance:     distance,
+		paddingX:     paddingX,
+		paddingY:     paddingY,
+		titleOffset:  titleOffset,
+	}
+}
+
+// viewport returns the visible area of the frame, which is the full canvas
+// unless the canvas is clipped to the window and its shadow
+func (s *Scaffold) viewport(fr frame) (x, y, width, height float64) {
+	if !s.clipCanvas {
+		return 0, 0, fr.width, fr.height
+	}
+
+	x, y = fr.x-s.factor, fr.y-s.factor
+	width, height = fr.windowWidth+2*s.factor, fr.windowHeight+2*s.factor
+	if s.drawShadow {
+		width += s.shadowOffsetX + float64(s.shadowRadius)
+		height += s.shadowOffsetY + float64(s.shadowRadius)
+	}
+
+	return x, y, width, height
+}
+
+// buttonX returns the horizontal center of the i-th window button
+func (fr frame) buttonX(s *Scaffold, i int) float64 {
+	return fr.x + fr.paddingX + float64(i)*fr.distance + s.factor*4
+}
 
-		bc := gg.NewContext(int(width), int(height))
-		bc.DrawRoundedRectangle(xOffset+s.shadowOffsetX, yOffset+s.shadowOffsetY, width-2*marginX, height-2*marginY, corner)
+// buttonY returns the vertical center of the window buttons
+func (fr frame) buttonY(s *Scaffold) float64 {
+	return fr.y + fr.paddingY + s.factor*4
+}
+
+// glyph is a single visible rune of the content with its position inside
+// the window, where x and y denote the start of the text baseline
+type glyph struct {
+	cr   bunt.ColoredRune
+	str  string
+	x, y float64
+	w, h float64
+}
+
+// glyphs lays out the content inside the provided frame, line breaks and
+// tabs are resolved into positions and are therefore not part of the result
+func (s *Scaffold) glyphs(fr frame) []glyph {
+	var result = make([]glyph, 0, len(s.content))
+
+	var x, y = fr.x + fr.paddingX, fr.y + fr.paddingY + fr.titleOffset + s.fontHeight()
+	for _, cr := range s.content {
+		face := s.fontFace(cr)
+
+		str := string(cr.Symbol)
+		w := float64(imgfont.MeasureString(face, str) >> 6)
+		h := float64(face.Metrics().Height) / 64
+
+		switch str {
+		case "\n":
+			x = fr.x + fr.paddingX
+			y += h * s.lineSpacing
+			continue
+
+		case "\t":
+			x += w * float64(s.tabSpaces)
+			continue
+
+		case "✗", "ˣ": // mitigate issue #1 by replacing it with a similar character
+			str = "×"
+		}
+
+		result = append(result, glyph{cr: cr, str: str, x: x, y: y, w: w, h: h})
+		x += w
+	}
+
+	return result
+}
+
+// foregroundColor returns the color to be used for the text of the rune,
+// which is either the theme or RGB color, or the default foreground color
+func (s *Scaffold) foregroundColor(cr bunt.ColoredRune) color.Color {
+	fg, ok := ansi.Foreground(cr.Settings)
+	if !ok {
+		return s.defaultForegroundColor
+	}
+
+	return s.themeColor(fg)
+}
+
+// backgroundColor returns the theme or RGB background color of the rune,
+// in case the rune has a background color at all
+func (s *Scaffold) backgroundColor(cr bunt.ColoredRune) (color.Color, bool) {
+	bg, ok := ansi.Background(cr.Settings)
+	if !ok {
+		return nil, false
+	}
+
+	return s.themeColor(bg), true
+}
+
+func (s *Scaffold) image() (image.Image, error) {
+	var f = func(value float64) float64 { return s.factor * value }
+
+	var fr = s.frame()
+
+	dc := gg.NewContext(int(fr.width), int(fr.height))
+
+	// Optional: Apply blurred rounded rectangle to mimic the window shadow
+	//
+	if s.drawShadow {
+		bc := gg.NewContext(int(fr.width), int(fr.height))
+		bc.DrawRoundedRectangle(fr.x+s.shadowOffsetX, fr.y+s.shadowOffsetY, fr.windowWidth, fr.windowHeight, fr.corner)
 		bc.SetHexColor(s.shadowBaseColor)
 		bc.Fill()
 
@@ -652,11 +809,11 @@ func (s *Scaffold) image() (image.Image, error) {
 
 	// Draw rounded rectangle with outline to produce impression of a window
 	//
-	dc.DrawRoundedRectangle(xOffset, yOffset, width-2*marginX, height-2*marginY, corner)
+	dc.DrawRoundedRectangle(fr.x, fr.y, fr.windowWidth, fr.windowHeight, fr.corner)
 	dc.SetHexColor(s.currentTheme.Background)
 	dc.Fill()
 
-	dc.DrawRoundedRectangle(xOffset, yOffset, width-2*marginX, height-2*marginY, corner)
+	dc.DrawRoundedRectangle(fr.x, fr.y, fr.windowWidth, fr.windowHeight, fr.corner)
 	dc.SetHexColor(s.currentTheme.WindowBorder)
 	dc.SetLineWidth(f(1))
 	dc.Stroke()
@@ -665,9 +822,8 @@ func (s *Scaffold) image() (image.Image, error) {
 	// impression of an actional window
 	//
 	if s.drawDecorations {
-		colors := []string{s.currentTheme.WindowRed, s.currentTheme.WindowYellow, s.currentTheme.WindowGreen}
-		for i, color := range colors {
-			dc.DrawCircle(xOffset+paddingX+float64(i)*distance+f(4), yOffset+paddingY+f(4), radius)
+		for i, color := range s.buttonColors() {
+			dc.DrawCircle(fr.buttonX(s, i), fr.buttonY(s), fr.radius)
 			dc.SetHexColor(color)
 			dc.Fill()
 		}
@@ -675,80 +831,27 @@ func (s *Scaffold) image() (image.Image, error) {
 
 	// Apply the actual text into the prepared content area of the window
 	//
-	var x, y = xOffset + paddingX, yOffset + paddingY + titleOffset + s.fontHeight()
-	for _, cr := range s.content {
-		switch cr.Settings & 0x1C {
-		case 4:
-			dc.SetFontFace(s.bold)
-
-		case 8:
-			dc.SetFontFace(s.italic)
-
-		case 12:
-			dc.SetFontFace(s.boldItalic)
-
-		default:
-			dc.SetFontFace(s.regular)
-		}
-
-		str := string(cr.Symbol)
-		w, h := dc.MeasureString(str)
+	for _, g := range s.glyphs(fr) {
+		dc.SetFontFace(s.fontFace(g.cr))
 
 		// background color
-		switch cr.Settings & 0x02 { //nolint:gocritic
-		case 2:
-			bgR := int((cr.Settings >> 32) & 0xFF)
-			bgG := int((cr.Settings >> 40) & 0xFF)
-			bgB := int((cr.Settings >> 48) & 0xFF)
-			
-			// Remap ANSI colors to theme colors
-			remappedBg := s.remapAnsiColor(bgR, bgG, bgB)
-			dc.SetColor(remappedBg)
-
-			dc.DrawRectangle(x, y-h+12, w, h)
+		if bg, ok := s.backgroundColor(g.cr); ok {
+			dc.SetColor(bg)
+			dc.DrawRectangle(g.x, g.y-g.h+12, g.w, g.h)
 			dc.Fill()
 		}
 
 		// foreground color
-		switch cr.Settings & 0x01 {
-		case 1:
-			fgR := int((cr.Settings >> 8) & 0xFF)
-			fgG := int((cr.Settings >> 16) & 0xFF)
-			fgB := int((cr.Settings >> 24) & 0xFF)
-			
-			// Remap ANSI colors to theme colors
-			remappedFg := s.remapAnsiColor(fgR, fgG, fgB)
-			dc.SetColor(remappedFg)
-
-		default:
-			dc.SetColor(s.defaultForegroundColor)
-		}
-
-		switch str {
-		case "\n":
-			x = xOffset + paddingX
-			y += h * s.lineSpacing
-			continue
-
-		case "\t":
-			x += w * float64(s.tabSpaces)
-			continue
-
-		case "✗", "ˣ": // mitigate issue #1 by replacing it with a similar character
-			str = "×"
-		}
-
-		dc.DrawString(str, x, y)
+		dc.SetColor(s.foregroundColor(g.cr))
+		dc.DrawString(g.str, g.x, g.y)
 
 		// There seems to be no font face based way to do an underlined
 		// string, therefore manually draw a line under each character
-		if cr.Settings&0x1C == 16 {
-			dc.DrawLine(x, y+f(4), x+w, y+f(4))
+		if g.cr.Settings&0x1C == 16 {
+			dc.DrawLine(g.x, g.y+f(4), g.x+g.w, g.y+f(4))
 			dc.SetLineWidth(f(1))
 			dc.Stroke()
 		}
-
-		x += w
 	}
 
 	return dc.Image(), nil
@@ -768,8 +871,12 @@ func (s *Scaffold) WritePNG(w io.Writer) error {
 		return err
 	}
 
-	// Optional: Clip image to minimum size by removing all surrounding transparent pixels
-	//
+	return png.Encode(w, s.clip(img))
+}
+
+// clip optionally clips the image to the minimum size by removing all
+// surrounding transparent pixels
+func (s *Scaffold) clip(img image.Image) image.Image {
 	if s.clipCanvas {
 		if imgRGBA, ok := img.(*image.RGBA); ok {
 			var minX, minY = math.MaxInt, math.MaxInt
@@ -805,11 +912,11 @@ func (s *Scaffold) WritePNG(w io.Writer) error {
 		}
 	}
 
-	return png.Encode(w, img)
+	return img
 }
 
 // WriteRaw writes the scaffold content as-is into the provided writer
 func (s *Scaffold) WriteRaw(w io.Writer) error {
-	_, err := w.Write([]byte(s.content.String()))
+	_, err := w.Write([]byte(ansi.Resolve(s.content).String()))
 	return err
 }
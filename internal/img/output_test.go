@@ -22,13 +22,17 @@ package img_test
 
 import (
 	"bytes"
+	"image/gif"
+	"image/png"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	. "github.com/gonvenience/bunt"
 	. "github.com/homeport/termshot/internal/img"
+	"github.com/homeport/termshot/internal/theme"
 )
 
 var _ = Describe("Creating images", func() {
@@ -97,6 +101,228 @@ var _ = Describe("Creating images", func() {
 		})
 	})
 
+	Context("Use scaffold to create SVG file", func() {
+		var buf bytes.Buffer
+
+		BeforeEach(func() {
+			SetColorSettings(ON, ON)
+			buf.Reset()
+		})
+
+		It("should write the content as text elements", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader("foo<bar>"))).To(Succeed())
+			Expect(scaffold.WriteSVG(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("<svg "))
+			Expect(buf.String()).To(ContainSubstring(">foo&lt;bar&gt;</text>"))
+			Expect(buf.String()).To(ContainSubstring(`filter="url(#shadow)"`))
+		})
+
+		It("should write text emphasis and colors as attributes", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader(Sprintf("*bold* _italic_ ~underline~ MintCream{mint}")))).To(Succeed())
+			Expect(scaffold.WriteSVG(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`font-weight="bold">bold</text>`))
+			Expect(buf.String()).To(ContainSubstring(`font-style="italic">italic</text>`))
+			Expect(buf.String()).To(ContainSubstring(`text-decoration="underline">underline</text>`))
+			Expect(buf.String()).To(ContainSubstring(`fill="#f5fffa">mint</text>`))
+		})
+
+		It("should omit window decorations and shadow when configured", func() {
+			scaffold := NewImageCreator()
+			scaffold.DrawDecorations(false)
+			scaffold.DrawShadow(false)
+			Expect(scaffold.AddContent(strings.NewReader("foobar"))).To(Succeed())
+			Expect(scaffold.WriteSVG(&buf)).To(Succeed())
+			Expect(buf.String()).ToNot(ContainSubstring("<circle"))
+			Expect(buf.String()).ToNot(ContainSubstring("<filter"))
+		})
+
+		It("should only show the last lines with a fixed number of rows", func() {
+			scaffold := NewImageCreator()
+			scaffold.SetRows(2)
+			Expect(scaffold.AddContent(strings.NewReader("one\ntwo\nthree\n"))).To(Succeed())
+			Expect(scaffold.WriteSVG(&buf)).To(Succeed())
+			Expect(buf.String()).ToNot(ContainSubstring(">one</text>"))
+			Expect(buf.String()).To(ContainSubstring(">two</text>"))
+			Expect(buf.String()).To(ContainSubstring(">three</text>"))
+		})
+
+		It("should pad the content to a fixed number of rows", func() {
+			var short, fixed bytes.Buffer
+
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader("one"))).To(Succeed())
+			Expect(scaffold.WriteSVG(&short)).To(Succeed())
+
+			scaffold = NewImageCreator()
+			scaffold.SetRows(10)
+			Expect(scaffold.AddContent(strings.NewReader("one"))).To(Succeed())
+			Expect(scaffold.WriteSVG(&fixed)).To(Succeed())
+
+			Expect(svgHeight(fixed.String())).To(BeNumerically(">", svgHeight(short.String())))
+		})
+	})
+
+	Context("Use scaffold to create HTML file", func() {
+		var buf bytes.Buffer
+
+		BeforeEach(func() {
+			SetColorSettings(ON, ON)
+			buf.Reset()
+		})
+
+		It("should write a self-contained document with the content in a pre block", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader("foo <bar> & baz"))).To(Succeed())
+			Expect(scaffold.WriteHTML(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("<!DOCTYPE html>"))
+			Expect(buf.String()).To(ContainSubstring("<pre>foo &lt;bar&gt; &amp; baz</pre>"))
+			Expect(buf.String()).ToNot(ContainSubstring("<link"))
+			Expect(buf.String()).ToNot(ContainSubstring("<script"))
+		})
+
+		It("should write text emphasis and colors as styled spans", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader(Sprintf("*bold* MintCream{mint}")))).To(Succeed())
+			Expect(scaffold.WriteHTML(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`<span class="b">bold</span>`))
+			Expect(buf.String()).To(ContainSubstring(`<span style="color: #f5fffa">mint</span>`))
+		})
+
+		It("should use the theme for palette colors and keep RGB colors as they are", func() {
+			custom := theme.GetTheme("default")
+			custom.Palette = map[int]string{196: "#123456"}
+
+			scaffold := NewImageCreator()
+			scaffold.SetTheme(custom)
+			Expect(scaffold.AddContent(strings.NewReader("\x1b[31ma\x1b[38;5;196mb\x1b[38;5;46mc\x1b[38;2;128;0;0md\x1b[0m"))).To(Succeed())
+			Expect(scaffold.WriteHTML(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`<span style="color: #e06c75">a</span>`))
+			Expect(buf.String()).To(ContainSubstring(`<span style="color: #123456">b</span>`))
+			Expect(buf.String()).To(ContainSubstring(`<span style="color: #00ff00">c</span>`))
+			Expect(buf.String()).To(ContainSubstring(`<span style="color: #800000">d</span>`))
+		})
+
+		It("should use a fixed height with a fixed number of rows", func() {
+			scaffold := NewImageCreator()
+			scaffold.SetRows(24)
+			Expect(scaffold.AddContent(strings.NewReader("foobar"))).To(Succeed())
+			Expect(scaffold.WriteHTML(&buf)).To(Succeed())
+			Expect(buf.String()).ToNot(ContainSubstring("height: auto"))
+		})
+	})
+
+	Context("Use scaffold to create PDF file", func() {
+		var buf bytes.Buffer
+
+		BeforeEach(func() {
+			SetColorSettings(ON, ON)
+			buf.Reset()
+		})
+
+		It("should write a PDF document with embedded font subsets", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader(Sprintf("foobar *bold*")))).To(Succeed())
+			Expect(scaffold.WritePDF(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("%PDF-1.4"))
+			Expect(buf.String()).To(HaveSuffix("%%EOF\n"))
+			Expect(buf.String()).To(ContainSubstring("+Hack-Regular /Encoding /Identity-H"))
+			Expect(buf.String()).To(ContainSubstring("+Hack-Bold /Encoding /Identity-H"))
+			Expect(buf.String()).ToNot(ContainSubstring("Hack-Italic"))
+			Expect(buf.String()).To(ContainSubstring("/FontFile2"))
+			Expect(buf.String()).To(ContainSubstring("/ToUnicode"))
+			Expect(buf.Len()).To(BeNumerically("<", 100*1024))
+		})
+
+		It("should only underline the underlined text of a line", func() {
+			var underlines = func(text string) [][2]float64 {
+				var buf bytes.Buffer
+				scaffold := NewImageCreator()
+				Expect(scaffold.AddContent(strings.NewReader(Sprintf(text)))).To(Succeed())
+				Expect(scaffold.WritePDF(&buf)).To(Succeed())
+				return pdfUnderlines(buf.Bytes())
+			}
+
+			underlined := underlines("~under~")
+			Expect(underlined).To(HaveLen(1))
+			Expect(underlines("~under~ plain")).To(Equal(underlined))
+
+			trailing := underlines("plain ~under~")
+			Expect(trailing).To(HaveLen(1))
+			Expect(trailing[0][1] - trailing[0][0]).To(BeNumerically("~", underlined[0][1]-underlined[0][0], 0.01))
+			Expect(trailing[0][0]).To(BeNumerically(">", underlined[0][0]))
+		})
+	})
+
+	Context("Use scaffold to create animated files", func() {
+		var buf bytes.Buffer
+		var frames = []Frame{
+			{Content: []byte("foo\n"), Delay: 100 * time.Millisecond},
+			{Content: []byte("foo\nbar\n"), Delay: 2 * time.Second},
+		}
+
+		BeforeEach(func() {
+			SetColorSettings(ON, ON)
+			buf.Reset()
+		})
+
+		It("should write an animated GIF with one image per frame", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.WriteGIF(&buf, frames)).To(Succeed())
+
+			anim, err := gif.DecodeAll(&buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(anim.Image).To(HaveLen(2))
+			Expect(anim.Delay).To(Equal([]int{10, 200}))
+			Expect(anim.Image[0].Bounds()).To(Equal(anim.Image[1].Bounds()))
+		})
+
+		It("should write an animated PNG with one frame control chunk per frame", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.WriteAPNG(&buf, frames)).To(Succeed())
+
+			// the default image of an APNG is the first frame
+			_, err := png.Decode(bytes.NewReader(buf.Bytes()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bytes.Count(buf.Bytes(), []byte("acTL"))).To(Equal(1))
+			Expect(bytes.Count(buf.Bytes(), []byte("fcTL"))).To(Equal(2))
+			Expect(bytes.Count(buf.Bytes(), []byte("fdAT"))).To(Equal(1))
+		})
+
+		It("should write an animated WebP with the changed area of each frame", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.WriteWebP(&buf, frames)).To(Succeed())
+			Expect(string(buf.Bytes()[0:4])).To(Equal("RIFF"))
+			Expect(string(buf.Bytes()[8:12])).To(Equal("WEBP"))
+
+			rects, images, err := webpFrames(buf.Bytes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images).To(HaveLen(2))
+			Expect(images[0].Bounds().Size()).To(Equal(rects[0].Size()))
+			Expect(images[1].Bounds().Size()).To(Equal(rects[1].Size()))
+
+			// only the second line changes in the second frame
+			Expect(rects[1].Min.Y).To(BeNumerically(">", 0))
+			Expect(rects[0].Bounds().Intersect(rects[1])).To(Equal(rects[1]))
+		})
+
+		It("should type the command character by character with the prompt", func() {
+			scaffold := NewImageCreator()
+			typing := scaffold.TypingFrames("ls -l", 80*time.Millisecond, 40*time.Millisecond)
+			Expect(typing).To(HaveLen(6))
+			Expect(typing).To(Equal(scaffold.TypingFrames("ls -l", 80*time.Millisecond, 40*time.Millisecond)))
+			Expect(typing[0].Command).To(Equal(scaffold.CommandLine("")))
+			Expect(typing[5].Command).To(Equal(scaffold.CommandLine("ls -l")))
+
+			for _, frame := range typing[1:5] {
+				Expect(frame.Delay).To(BeNumerically("~", 80*time.Millisecond, 40*time.Millisecond))
+			}
+
+			Expect(scaffold.WriteGIF(&buf, append(typing, Frame{Command: scaffold.CommandLine("ls -l"), Content: []byte("foo\n")}))).To(Succeed())
+		})
+	})
+
 	Context("Use scaffold to create raw output file", func() {
 		var buf bytes.Buffer
 
@@ -111,5 +337,12 @@ var _ = Describe("Creating images", func() {
 			Expect(scaffold.WriteRaw(&buf)).To(Succeed())
 			Expect(buf.String()).To(Equal("\x1b[38;2;245;255;250mfoobar\x1b[0m"))
 		})
+
+		It("should write palette colors with their typical RGB values", func() {
+			scaffold := NewImageCreator()
+			Expect(scaffold.AddContent(strings.NewReader("\x1b[38;5;196mfoobar\x1b[0m"))).To(Succeed())
+			Expect(scaffold.WriteRaw(&buf)).To(Succeed())
+			Expect(buf.String()).To(Equal("\x1b[38;2;255;0;0mfoobar\x1b[0m"))
+		})
 	})
 })
@@ -0,0 +1,484 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gonvenience/bunt"
+	"github.com/homeport/termshot/internal/theme"
+)
+
+// kappa is the distance of the Bézier control points to approximate a
+// quarter circle
+const kappa = 0.5522847498
+
+// shadowLayers is the number of stacked shapes to approximate the blurred
+// window shadow, since PDF has no blur filter
+const shadowLayers = 12
+
+// hackFonts returns the parsed Hack font files in the order regular, bold,
+// italic, and bold italic
+var hackFonts = sync.OnceValues(func() ([]*ttf, error) {
+	var result []*ttf
+	for _, font := range []struct {
+		name         string
+		data         []byte
+		bold, italic bool
+	}{
+		{"Hack-Regular", hackRegular, false, false},
+		{"Hack-Bold", hackBold, true, false},
+		{"Hack-Italic", hackItalic, false, true},
+		{"Hack-BoldItalic", hackBoldItalic, true, true},
+	} {
+		parsed, err := parseTTF(font.name, font.data, font.bold, font.italic)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, parsed)
+	}
+
+	return result, nil
+})
+
+// pdfDocument keeps track of the objects of a PDF file
+type pdfDocument struct {
+	objects [][]byte
+}
+
+// reserve returns the number of a new object, which content is set later
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+// set sets the content of the object with the given number
+func (d *pdfDocument) set(id int, format string, a ...interface{}) {
+	d.objects[id-1] = []byte(fmt.Sprintf(format, a...))
+}
+
+// add adds a new object and returns its number
+func (d *pdfDocument) add(format string, a ...interface{}) int {
+	id := d.reserve()
+	d.set(id, format, a...)
+	return id
+}
+
+// stream adds a new compressed stream object and returns its number
+func (d *pdfDocument) stream(dict string, data []byte) (int, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	id := d.reserve()
+	d.objects[id-1] = append(
+		[]byte(fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, buf.Len())),
+		append(buf.Bytes(), []byte("\nendstream")...)...,
+	)
+
+	return id, nil
+}
+
+// write writes the document with the given root object
+func (d *pdfDocument) write(w io.Writer, root int) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(d.objects))
+	for i, object := range d.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(object)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, root, xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// pdfPage collects the drawing operations and the resources of the page
+type pdfPage struct {
+	content bytes.Buffer
+	alphas  map[string]float64
+}
+
+func (p *pdfPage) printf(format string, a ...interface{}) {
+	fmt.Fprintf(&p.content, format, a...)
+}
+
+// fill sets the fill color, including the transparency if needed
+func (p *pdfPage) fill(c color.Color, alpha float64) {
+	r, g, b, _ := c.RGBA()
+	p.printf("%s %s %s rg\n", num(float64(r>>8)/0xFF), num(float64(g>>8)/0xFF), num(float64(b>>8)/0xFF))
+	p.alpha(alpha)
+}
+
+// stroke sets the stroke color
+func (p *pdfPage) stroke(c color.Color) {
+	r, g, b, _ := c.RGBA()
+	p.printf("%s %s %s RG\n", num(float64(r>>8)/0xFF), num(float64(g>>8)/0xFF), num(float64(b>>8)/0xFF))
+}
+
+// alpha sets the transparency using a graphics state
+func (p *pdfPage) alpha(alpha float64) {
+	alpha = math.Round(alpha*1000) / 1000
+	name := fmt.Sprintf("GS%d", int(alpha*1000))
+	p.alphas[name] = alpha
+	p.printf("/%s gs\n", name)
+}
+
+// roundedRectangle adds the path of a rectangle with rounded corners
+func (p *pdfPage) roundedRectangle(x, y, w, h, r float64) {
+	r = math.Max(0, math.Min(r, math.Min(w, h)/2))
+	k := r * kappa
+
+	p.printf("%s %s m\n", num(x+r), num(y))
+	p.printf("%s %s l\n", num(x+w-r), num(y))
+	p.printf("%s %s %s %s %s %s c\n", num(x+w-r+k), num(y), num(x+w), num(y+r-k), num(x+w), num(y+r))
+	p.printf("%s %s l\n", num(x+w), num(y+h-r))
+	p.printf("%s %s %s %s %s %s c\n", num(x+w), num(y+h-r+k), num(x+w-r+k), num(y+h), num(x+w-r), num(y+h))
+	p.printf("%s %s l\n", num(x+r), num(y+h))
+	p.printf("%s %s %s %s %s %s c\n", num(x+r-k), num(y+h), num(x), num(y+h-r+k), num(x), num(y+h-r))
+	p.printf("%s %s l\n", num(x), num(y+r))
+	p.printf("%s %s %s %s %s %s c\nh\n", num(x), num(y+r-k), num(x+r-k), num(y), num(x+r), num(y))
+}
+
+// WritePDF writes the scaffold content as PDF into the provided writer, the
+// content is written as text using embedded subsets of the Hack font
+func (s *Scaffold) WritePDF(w io.Writer) error {
+	var f = func(value float64) float64 { return s.factor * value }
+
+	fonts, err := hackFonts()
+	if err != nil {
+		return err
+	}
+
+	var fr = s.frame()
+	var page = pdfPage{alphas: map[string]float64{}}
+
+	// PDF uses points and the origin is in the bottom left corner, so the
+	// coordinate system is flipped to use the same pixel based layout as
+	// the other output formats, sized so that the font has its point size
+	var scale = 72 / (s.factor * defaultFontDPI)
+	minX, minY, width, height := s.viewport(fr)
+	page.printf("%s 0 0 %s %s %s cm\n", num(scale), num(-scale), num(-minX*scale), num((minY+height)*scale))
+
+	// Optional: Apply stacked translucent rounded rectangles to mimic the
+	// blurred window shadow
+	//
+	if s.drawShadow {
+		if shadow, err := theme.ParseColor(s.shadowBaseColor); err == nil {
+			// each layer is translucent so that the fully overlapping center
+			// has the opacity of the shadow color
+			_, _, _, a := shadow.RGBA()
+			alpha := 1 - math.Pow(1-float64(a>>8)/0xFF, 1.0/shadowLayers)
+
+			radius := float64(s.shadowRadius)
+			for i := 0; i < shadowLayers; i++ {
+				spread := radius * (1 - float64(2*i+1)/shadowLayers)
+				page.fill(shadow, alpha)
+				page.roundedRectangle(
+					fr.x+s.shadowOffsetX-spread,
+					fr.y+s.shadowOffsetY-spread,
+					fr.windowWidth+2*spread,
+					fr.windowHeight+2*spread,
+					fr.corner+math.Max(0, spread),
+				)
+				page.printf("f\n")
+			}
+		}
+	}
+
+	// Draw rounded rectangle with outline to produce impression of a window
+	//
+	if bg, err := theme.ParseColor(s.currentTheme.Background); err == nil {
+		page.fill(bg, 1)
+		page.roundedRectangle(fr.x, fr.y, fr.windowWidth, fr.windowHeight, fr.corner)
+		page.printf("f\n")
+	}
+
+	if border, err := theme.ParseColor(s.currentTheme.WindowBorder); err == nil {
+		page.stroke(border)
+		page.printf("%s w\n", num(f(1)))
+		page.roundedRectangle(fr.x, fr.y, fr.windowWidth, fr.windowHeight, fr.corner)
+		page.printf("S\n")
+	}
+
+	// Optional: Draw window decorations (i.e. three buttons)
+	//
+	if s.drawDecorations {
+		for i, hex := range s.buttonColors() {
+			if c, err := theme.ParseColor(hex); err == nil {
+				page.fill(c, 1)
+				page.roundedRectangle(fr.buttonX(s, i)-fr.radius, fr.buttonY(s)-fr.radius, 2*fr.radius, 2*fr.radius, fr.radius)
+				page.printf("f\n")
+			}
+		}
+	}
+
+	glyphs := s.glyphs(fr)
+
+	// Backgrounds go first, so that they do not overlap the text of
+	// neighboring glyphs
+	//
+	for _, g := range glyphs {
+		if bg, ok := s.backgroundColor(g.cr); ok {
+			page.fill(bg, 1)
+			page.printf("%s %s %s %s re f\n", num(g.x), num(g.y-g.h+12), num(g.w), num(g.h))
+		}
+	}
+
+	// Text is written in runs of glyphs with the same style, glyph advances
+	// are corrected to match the pixel based layout of the image output
+	//
+	var used = make([]map[uint16]rune, len(fonts))
+	for i := range used {
+		used[i] = map[uint16]rune{}
+	}
+
+	var size = s.fontSize()
+	for i := 0; i < len(glyphs); {
+		start, fg := glyphs[i], s.foregroundColor(glyphs[i].cr)
+		font := pdfFontIndex(start.cr)
+
+		var run bytes.Buffer
+		j := i
+		for ; j < len(glyphs); j++ {
+			g := glyphs[j]
+			if g.y != start.y || g.cr.Settings&0x1C != start.cr.Settings&0x1C || pdfFontIndex(g.cr) != font || hexColor(s.foregroundColor(g.cr)) != hexColor(fg) {
+				break
+			}
+
+			if j > i && math.Abs(glyphs[j-1].x+glyphs[j-1].w-g.x) > 0.5 {
+				break
+			}
+
+			gid := fonts[font].index([]rune(g.str)[0])
+			used[font][gid] = []rune(g.str)[0]
+
+			advance := float64(fonts[font].advance(gid)) / float64(fonts[font].unitsPerEm) * size
+			fmt.Fprintf(&run, "<%04X> %s ", gid, num((advance-g.w)*1000/size))
+		}
+
+		page.fill(fg, 1)
+		page.printf("BT\n/F%d %s Tf\n1 0 0 -1 %s %s Tm\n[%s] TJ\nET\n", font, num(size), num(start.x), num(start.y), strings.TrimSpace(run.String()))
+
+		// Underline is drawn manually in the same way it is in the image
+		if start.cr.Settings&0x1C == 16 {
+			last := glyphs[j-1]
+			page.stroke(fg)
+			page.printf("%s w\n%s %s m %s %s l S\n", num(f(1)), num(start.x), num(start.y+f(4)), num(last.x+last.w), num(start.y+f(4)))
+		}
+
+		i = j
+	}
+
+	// Assemble the document with the page, its resources, and the fonts
+	//
+	var doc pdfDocument
+	catalog, pages := doc.reserve(), doc.reserve()
+
+	contentID, err := doc.stream("", page.content.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var fontRefs []string
+	for i, font := range fonts {
+		if len(used[i]) == 0 {
+			continue
+		}
+
+		id, err := doc.embedFont(font, used[i])
+		if err != nil {
+			return err
+		}
+
+		fontRefs = append(fontRefs, fmt.Sprintf("/F%d %d 0 R", i, id))
+	}
+
+	var names []string
+	for name := range page.alphas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var states []string
+	for _, name := range names {
+		states = append(states, fmt.Sprintf("/%s << /Type /ExtGState /ca %g /CA %g >>", name, page.alphas[name], page.alphas[name]))
+	}
+
+	pageID := doc.add("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << /Font << %s >> /ExtGState << %s >> >> >>",
+		pages, num(width*scale), num(height*scale), contentID, strings.Join(fontRefs, " "), strings.Join(states, " "))
+
+	doc.set(pages, "<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", pageID)
+	doc.set(catalog, "<< /Type /Catalog /Pages %d 0 R >>", pages)
+
+	return doc.write(w, catalog)
+}
+
+// embedFont adds a font subset with the given glyphs to the document and
+// returns the number of the font object
+func (d *pdfDocument) embedFont(font *ttf, glyphs map[uint16]rune) (int, error) {
+	var scale = func(value int) string { return num(float64(value) * 1000 / float64(font.unitsPerEm)) }
+
+	var gids []int
+	for gid := range glyphs {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	// Font subsets are named using a tag that is based on the glyphs
+	hash := fnv.New32a()
+	for _, gid := range gids {
+		fmt.Fprintf(hash, "%d,", gid)
+	}
+
+	var tag strings.Builder
+	for sum := hash.Sum32(); tag.Len() < 6; sum /= 26 {
+		tag.WriteByte(byte('A' + sum%26))
+	}
+
+	name := tag.String() + "+" + font.name
+
+	subset := font.subset(glyphs)
+	fontFile, err := d.stream(fmt.Sprintf("/Length1 %d", len(subset)), subset)
+	if err != nil {
+		return 0, err
+	}
+
+	var flags = 1 | 32 // fixed pitch, non-symbolic
+	var italicAngle, stemV = 0, 80
+	if font.italic {
+		flags |= 64
+		italicAngle = -11
+	}
+
+	if font.bold {
+		stemV = 140
+	}
+
+	descriptor := d.add("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%s %s %s %s] /ItalicAngle %d /Ascent %s /Descent %s /CapHeight %s /StemV %d /FontFile2 %d 0 R >>",
+		name, flags,
+		scale(font.metric("head", 36)), scale(font.metric("head", 38)), scale(font.metric("head", 40)), scale(font.metric("head", 42)),
+		italicAngle,
+		scale(font.metric("hhea", 4)), scale(font.metric("hhea", 6)), scale(font.metric("hhea", 4)),
+		stemV,
+		fontFile,
+	)
+
+	var widths []string
+	for _, gid := range gids {
+		widths = append(widths, fmt.Sprintf("%d [%s]", gid, scale(font.advance(uint16(gid)))))
+	}
+
+	cidFont := d.add("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		name, descriptor, strings.Join(widths, " "))
+
+	toUnicode, err := d.stream("", toUnicodeCMap(gids, glyphs))
+	if err != nil {
+		return 0, err
+	}
+
+	return d.add("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cidFont, toUnicode), nil
+}
+
+// toUnicodeCMap creates the character map that maps glyphs back to text, so
+// that the text in the document can be selected and copied
+func toUnicodeCMap(gids []int, glyphs map[uint16]rune) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	buf.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	buf.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	buf.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	for len(gids) > 0 {
+		chunk := gids[:int(math.Min(100, float64(len(gids))))]
+		gids = gids[len(chunk):]
+
+		fmt.Fprintf(&buf, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			var utf16 strings.Builder
+			for _, unit := range utf16Units(glyphs[uint16(gid)]) {
+				fmt.Fprintf(&utf16, "%04X", unit)
+			}
+
+			fmt.Fprintf(&buf, "<%04X> <%s>\n", gid, utf16.String())
+		}
+		buf.WriteString("endbfchar\n")
+	}
+
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buf.Bytes()
+}
+
+// utf16Units returns the UTF-16 code units of the rune
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + (r>>10)&0x3FF), uint16(0xDC00 + r&0x3FF)}
+}
+
+// pdfFontIndex returns the index of the font matching the text emphasis, it
+// uses the same selection as the image output
+func pdfFontIndex(cr bunt.ColoredRune) int {
+	switch cr.Settings & 0x1C {
+	case 4:
+		return 1
+
+	case 8:
+		return 2
+
+	case 12:
+		return 3
+
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/homeport/termshot/internal/theme"
+)
+
+// svgFontFamily is the list of fonts the SVG viewer should try, the Hack
+// font is used for the PNG output and therefore the preferred choice
+const svgFontFamily = "Hack, 'DejaVu Sans Mono', Menlo, Consolas, monospace"
+
+// WriteSVG writes the scaffold content as SVG into the provided writer, the
+// content is written as text elements so that it is selectable and scales
+// without any loss in quality
+func (s *Scaffold) WriteSVG(w io.Writer) error {
+	var f = func(value float64) float64 { return s.factor * value }
+
+	var fr = s.frame()
+	var buf bytes.Buffer
+
+	minX, minY, width, height := s.viewport(fr)
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s">`+"\n",
+		num(width), num(height), num(minX), num(minY), num(width), num(height))
+
+	// Optional: Apply blurred rounded rectangle to mimic the window shadow
+	//
+	if s.drawShadow {
+		fmt.Fprintf(&buf, `<defs><filter id="shadow" x="-50%%" y="-50%%" width="200%%" height="200%%"><feGaussianBlur stdDeviation="%s"/></filter></defs>`+"\n",
+			num(float64(s.shadowRadius)/2))
+
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s" %s filter="url(#shadow)"/>`+"\n",
+			num(fr.x+s.shadowOffsetX), num(fr.y+s.shadowOffsetY), num(fr.windowWidth), num(fr.windowHeight), num(fr.corner),
+			svgPaint("fill", s.shadowBaseColor))
+	}
+
+	// Draw rounded rectangle with outline to produce impression of a window
+	//
+	fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s" %s %s stroke-width="%s"/>`+"\n",
+		num(fr.x), num(fr.y), num(fr.windowWidth), num(fr.windowHeight), num(fr.corner),
+		svgPaint("fill", s.currentTheme.Background),
+		svgPaint("stroke", s.currentTheme.WindowBorder),
+		num(f(1)))
+
+	// Optional: Draw window decorations (i.e. three buttons)
+	//
+	if s.drawDecorations {
+		for i, color := range s.buttonColors() {
+			fmt.Fprintf(&buf, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n",
+				num(fr.buttonX(s, i)), num(fr.buttonY(s)), num(fr.radius),
+				svgPaint("fill", color))
+		}
+	}
+
+	glyphs := s.glyphs(fr)
+
+	// Backgrounds go first, so that they do not overlap the text of
+	// neighboring glyphs
+	//
+	for _, g := range glyphs {
+		if bg, ok := s.backgroundColor(g.cr); ok {
+			fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+				num(g.x), num(g.y-g.h+12), num(g.w), num(g.h), hexColor(bg))
+		}
+	}
+
+	fmt.Fprintf(&buf, `<g font-family="%s" font-size="%s" xml:space="preserve">`+"\n",
+		svgFontFamily, num(s.fontSize()))
+
+	// Consecutive glyphs on the same line with the same style are combined
+	// into one text element to keep the document reasonably small
+	//
+	for i := 0; i < len(glyphs); {
+		start, fg := glyphs[i], hexColor(s.foregroundColor(glyphs[i].cr))
+
+		var text bytes.Buffer
+		j := i
+		for ; j < len(glyphs); j++ {
+			g := glyphs[j]
+			if g.y != start.y || g.cr.Settings&0x1C != start.cr.Settings&0x1C || hexColor(s.foregroundColor(g.cr)) != fg {
+				break
+			}
+
+			// glyphs are only combined if there is no gap, e.g. a tab
+			if j > i && math.Abs(glyphs[j-1].x+glyphs[j-1].w-g.x) > 0.5 {
+				break
+			}
+
+			if err := xml.EscapeText(&text, []byte(g.str)); err != nil {
+				return err
+			}
+		}
+
+		var attributes string
+		if start.cr.Settings&0x04 != 0 {
+			attributes += ` font-weight="bold"`
+		}
+
+		if start.cr.Settings&0x08 != 0 {
+			attributes += ` font-style="italic"`
+		}
+
+		if start.cr.Settings&0x10 != 0 {
+			attributes += ` text-decoration="underline"`
+		}
+
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" fill="%s"%s>%s</text>`+"\n",
+			num(start.x), num(start.y), fg, attributes, text.String())
+
+		i = j
+	}
+
+	buf.WriteString("</g>\n</svg>\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// fontSize returns the size of the font in pixels
+func (s *Scaffold) fontSize() float64 {
+	return s.factor * defaultFontSize * defaultFontDPI / 72
+}
+
+// svgPaint returns the SVG paint attribute for the given theme color, which
+// includes an additional opacity attribute in case the color has an alpha
+// channel
+func svgPaint(attribute string, hex string) string {
+	c, err := theme.ParseColor(hex)
+	if err != nil {
+		return fmt.Sprintf(`%s="none"`, attribute)
+	}
+
+	if _, _, _, a := c.RGBA(); a>>8 != 0xFF {
+		return fmt.Sprintf(`%s="%s" %s-opacity="%s"`, attribute, hexColor(c), attribute, num(float64(a>>8)/0xFF))
+	}
+
+	return fmt.Sprintf(`%s="%s"`, attribute, hexColor(c))
+}
+
+// hexColor returns the hex notation of the color, ignoring the alpha channel
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// num formats a number with at most two decimals
+func num(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
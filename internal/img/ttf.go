@@ -0,0 +1,328 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/golang/freetype/truetype"
+)
+
+//go:embed fonts/Hack-Regular.ttf
+var hackRegular []byte
+
+//go:embed fonts/Hack-Bold.ttf
+var hackBold []byte
+
+//go:embed fonts/Hack-Italic.ttf
+var hackItalic []byte
+
+//go:embed fonts/Hack-BoldItalic.ttf
+var hackBoldItalic []byte
+
+// subsetTables are the TrueType tables required to render glyphs, all other
+// tables (e.g. cmap, or name) are not needed when the font is embedded into
+// a document that addresses glyphs directly by their index
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// ttf is a parsed TrueType font with the raw table data, which is required
+// to create font subsets
+type ttf struct {
+	name   string
+	italic bool
+	bold   bool
+
+	font   *truetype.Font
+	tables map[string][]byte
+
+	unitsPerEm       int
+	numGlyphs        int
+	numberOfHMetrics int
+}
+
+func parseTTF(name string, data []byte, bold bool, italic bool) (*ttf, error) {
+	font, err := truetype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %w", name, err)
+	}
+
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font %s is too short", name)
+	}
+
+	result := ttf{
+		name:   name,
+		bold:   bold,
+		italic: italic,
+		font:   font,
+		tables: map[string][]byte{},
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, fmt.Errorf("font %s has a truncated table directory", name)
+		}
+
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("font %s has a truncated table %q", name, tag)
+		}
+
+		result.tables[tag] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"glyf", "head", "hhea", "hmtx", "loca", "maxp"} {
+		if _, ok := result.tables[tag]; !ok {
+			return nil, fmt.Errorf("font %s has no %q table", name, tag)
+		}
+	}
+
+	result.unitsPerEm = int(binary.BigEndian.Uint16(result.tables["head"][18:]))
+	result.numGlyphs = int(binary.BigEndian.Uint16(result.tables["maxp"][4:]))
+	result.numberOfHMetrics = int(binary.BigEndian.Uint16(result.tables["hhea"][34:]))
+
+	return &result, nil
+}
+
+// index returns the glyph index of the rune
+func (t *ttf) index(r rune) uint16 {
+	return uint16(t.font.Index(r))
+}
+
+// advance returns the advance width of the glyph in font units
+func (t *ttf) advance(gid uint16) int {
+	hmtx := t.tables["hmtx"]
+
+	i := int(gid)
+	if i >= t.numberOfHMetrics {
+		i = t.numberOfHMetrics - 1
+	}
+
+	return int(binary.BigEndian.Uint16(hmtx[4*i:]))
+}
+
+// metric returns a signed value of the given table in font units
+func (t *ttf) metric(table string, offset int) int {
+	return int(int16(binary.BigEndian.Uint16(t.tables[table][offset:])))
+}
+
+// glyph returns the raw glyph data
+func (t *ttf) glyph(gid uint16) []byte {
+	var loca, glyf = t.tables["loca"], t.tables["glyf"]
+
+	var start, end int
+	switch t.metric("head", 50) {
+	case 0:
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid):]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid)+2:]))
+
+	default:
+		start = int(binary.BigEndian.Uint32(loca[4*int(gid):]))
+		end = int(binary.BigEndian.Uint32(loca[4*int(gid)+4:]))
+	}
+
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+
+	return glyf[start:end]
+}
+
+// components returns the glyph indices a composite glyph is made of
+func (t *ttf) components(gid uint16) []uint16 {
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+
+	data := t.glyph(gid)
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+
+	var result []uint16
+	for pos := 10; pos+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		result = append(result, binary.BigEndian.Uint16(data[pos+2:]))
+		pos += 4
+
+		switch {
+		case flags&argsAreWords != 0:
+			pos += 4
+		default:
+			pos += 2
+		}
+
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+
+	return result
+}
+
+// subset creates a TrueType font file that only contains the glyph outlines
+// of the provided glyphs, all glyph indices stay the same so that no mapping
+// is required for the document using the font subset
+func (t *ttf) subset(glyphs map[uint16]rune) []byte {
+	// Make sure the undefined glyph and all components of composite glyphs
+	// are included
+	var used = map[uint16]struct{}{0: {}}
+	var queue = []uint16{0}
+	for gid := range glyphs {
+		queue = append(queue, gid)
+	}
+
+	for len(queue) > 0 {
+		gid := queue[0]
+		queue = queue[1:]
+
+		used[gid] = struct{}{}
+		for _, component := range t.components(gid) {
+			if _, ok := used[component]; !ok {
+				queue = append(queue, component)
+			}
+		}
+	}
+
+	// Rebuild glyph table and glyph locations (always in the long format)
+	var glyf bytes.Buffer
+	var loca = make([]byte, 4*(t.numGlyphs+1))
+	for gid := 0; gid < t.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[4*gid:], uint32(glyf.Len()))
+		if _, ok := used[uint16(gid)]; ok {
+			glyf.Write(t.glyph(uint16(gid)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*t.numGlyphs:], uint32(glyf.Len()))
+
+	// Update head table to the long location format and reset the checksum
+	// adjustment, which is calculated once the file is complete
+	head := append([]byte{}, t.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{}
+	for _, tag := range subsetTables {
+		if data, ok := t.tables[tag]; ok {
+			tables[tag] = data
+		}
+	}
+
+	tables["glyf"] = glyf.Bytes()
+	tables["loca"] = loca
+	tables["head"] = head
+
+	result := assembleTTF(tables)
+	binary.BigEndian.PutUint32(result[headOffset(result):][8:], 0xB1B0AFBA-checksum(result))
+
+	return result
+}
+
+// assembleTTF writes the given tables into a TrueType font file
+func assembleTTF(tables map[string][]byte) []byte {
+	var tags []string
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var numTables = len(tags)
+	var entrySelector = 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+
+	var searchRange = 16 * (1 << entrySelector)
+
+	var header = make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header[0:], 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*numTables-searchRange))
+
+	var body bytes.Buffer
+	for i, tag := range tags {
+		data := tables[tag]
+
+		record := header[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(data))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(header)+body.Len()))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(data)))
+
+		body.Write(data)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	return append(header, body.Bytes()...)
+}
+
+// headOffset returns the file offset of the head table
+func headOffset(data []byte) int {
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		if string(record[:4]) == "head" {
+			return int(binary.BigEndian.Uint32(record[8:]))
+		}
+	}
+
+	return 0
+}
+
+// checksum calculates the TrueType checksum of the data
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var chunk [4]byte
+		copy(chunk[:], data[i:])
+		sum += binary.BigEndian.Uint32(chunk[:])
+	}
+
+	return sum
+}
@@ -0,0 +1,513 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package img
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// VP8L (lossless WebP) bitstream constants
+const (
+	vp8lSignature   = 0x2f
+	vp8lLengthCodes = 24
+	vp8lDistCodes   = 40
+	vp8lCacheBits   = 10
+	vp8lMinLength   = 3
+	vp8lMaxLength   = 4096
+
+	// distance codes of the pixel above and the pixel to the left, which
+	// are the first two entries of the two-dimensional distance map
+	vp8lDistAbove = 1
+	vp8lDistLeft  = 2
+)
+
+// order in which the code lengths of the code length code are written
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// WriteWebP writes the frames as an animated WebP into the provided writer,
+// the frames are lossless and only contain the area that changed compared
+// to the previous frame
+func (s *Scaffold) WriteWebP(w io.Writer, frames []Frame) error {
+	images, err := s.frameImages(frames)
+	if err != nil {
+		return err
+	}
+
+	if len(images) == 0 {
+		return fmt.Errorf("no frames to write")
+	}
+
+	// frames without any change extend the duration of the previous frame
+	type part struct {
+		img   *image.RGBA
+		rect  image.Rectangle
+		delay time.Duration
+	}
+
+	var parts []part
+	var bounds = images[0].Bounds()
+	for i, img := range images {
+		rect := bounds
+		if i > 0 {
+			rect = changedArea(images[i-1], img)
+			if rect.Empty() {
+				parts[len(parts)-1].delay += frames[i].Delay
+				continue
+			}
+
+			// frame offsets are stored divided by two
+			rect.Min.X, rect.Min.Y = rect.Min.X&^1, rect.Min.Y&^1
+		}
+
+		parts = append(parts, part{img: img, rect: rect, delay: frames[i].Delay})
+	}
+
+	var chunks bytes.Buffer
+
+	// extended format with animation (0x02) and alpha (0x10)
+	var header bytes.Buffer
+	header.Write([]byte{0x12, 0, 0, 0})
+	header.Write(uint24(bounds.Dx() - 1))
+	header.Write(uint24(bounds.Dy() - 1))
+	writeRIFFChunk(&chunks, "VP8X", header.Bytes())
+
+	// transparent background color, and an infinite loop
+	writeRIFFChunk(&chunks, "ANIM", []byte{0, 0, 0, 0, 0, 0})
+
+	for _, p := range parts {
+		var frame bytes.Buffer
+		frame.Write(uint24(p.rect.Min.X / 2))
+		frame.Write(uint24(p.rect.Min.Y / 2))
+		frame.Write(uint24(p.rect.Dx() - 1))
+		frame.Write(uint24(p.rect.Dy() - 1))
+		frame.Write(uint24(int(min(p.delay/time.Millisecond, 0xFFFFFF))))
+
+		// do not blend with the previous frame (0x02), and do not dispose
+		frame.WriteByte(0x02)
+
+		writeRIFFChunk(&frame, "VP8L", vp8lImageData(p.img, p.rect))
+		writeRIFFChunk(&chunks, "ANMF", frame.Bytes())
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+chunks.Len()))
+	buf.WriteString("WEBP")
+	_, _ = chunks.WriteTo(&buf)
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// changedArea returns the smallest rectangle that contains all pixels that
+// are different in both images of the same size
+func changedArea(previous, current *image.RGBA) image.Rectangle {
+	var result image.Rectangle
+	var bounds = current.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		a := previous.Pix[previous.PixOffset(bounds.Min.X, y):previous.PixOffset(bounds.Max.X, y)]
+		b := current.Pix[current.PixOffset(bounds.Min.X, y):current.PixOffset(bounds.Max.X, y)]
+		if bytes.Equal(a, b) {
+			continue
+		}
+
+		var minX, maxX = bounds.Max.X, bounds.Min.X
+		for x := 0; x < len(a); x += 4 {
+			if !bytes.Equal(a[x:x+4], b[x:x+4]) {
+				minX = min(minX, bounds.Min.X+x/4)
+				maxX = max(maxX, bounds.Min.X+x/4+1)
+			}
+		}
+
+		result = result.Union(image.Rect(minX, y, maxX, y+1))
+	}
+
+	return result
+}
+
+// uint24 returns the value as a 24-bit little endian number
+func uint24(value int) []byte {
+	return []byte{byte(value), byte(value >> 8), byte(value >> 16)}
+}
+
+// writeRIFFChunk writes a RIFF chunk, chunks with an odd size are padded
+func writeRIFFChunk(w *bytes.Buffer, name string, data []byte) {
+	w.WriteString(name)
+	_ = binary.Write(w, binary.LittleEndian, uint32(len(data)))
+	w.Write(data)
+
+	if len(data)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+// vp8lToken is a literal pixel, a color cache entry, or a backward reference
+// to pixels that were already written
+type vp8lToken struct {
+	argb   uint32
+	cached int
+	length int
+	dist   int
+}
+
+// vp8lImageData encodes the given area of the image as a lossless WebP
+// bitstream without transforms, using a color cache and backward references
+// to the pixel to the left or the pixel above for repeated pixels
+func vp8lImageData(img *image.RGBA, rect image.Rectangle) []byte {
+	var width, height = rect.Dx(), rect.Dy()
+
+	var pixels = make([]uint32, 0, width*height)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.RGBAAt(x, y)).(color.NRGBA)
+			pixels = append(pixels, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+
+	var cache [1 << vp8lCacheBits]uint32
+	var cached [1 << vp8lCacheBits]bool
+	var insert = func(argb uint32) int {
+		idx := int((argb * 0x1e35a7bd) >> (32 - vp8lCacheBits))
+		hit := cached[idx] && cache[idx] == argb
+		cache[idx], cached[idx] = argb, true
+		if hit {
+			return idx
+		}
+
+		return -1
+	}
+
+	var green = make([]int, 256+vp8lLengthCodes+1<<vp8lCacheBits)
+	var red, blue, alpha = make([]int, 256), make([]int, 256), make([]int, 256)
+	var dist = make([]int, vp8lDistCodes)
+
+	var tokens []vp8lToken
+	for i := 0; i < len(pixels); {
+		length, distCode := matchLength(pixels, i, 1), vp8lDistLeft
+		if i >= width {
+			if above := matchLength(pixels, i, width); above > length {
+				length, distCode = above, vp8lDistAbove
+			}
+		}
+
+		if length >= vp8lMinLength {
+			for _, argb := range pixels[i : i+length] {
+				insert(argb)
+			}
+
+			lengthSymbol, _, _ := prefixEncode(length)
+			distSymbol, _, _ := prefixEncode(distCode)
+			green[256+lengthSymbol]++
+			dist[distSymbol]++
+
+			tokens = append(tokens, vp8lToken{length: length, dist: distCode})
+			i += length
+			continue
+		}
+
+		argb := pixels[i]
+		if idx := insert(argb); idx >= 0 {
+			green[256+vp8lLengthCodes+idx]++
+			tokens = append(tokens, vp8lToken{cached: idx})
+		} else {
+			green[argb>>8&0xFF]++
+			red[argb>>16&0xFF]++
+			blue[argb&0xFF]++
+			alpha[argb>>24]++
+			tokens = append(tokens, vp8lToken{argb: argb, cached: -1})
+		}
+
+		i++
+	}
+
+	var w bitWriter
+	w.write(vp8lSignature, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(1, 1) // alpha is used
+	w.write(0, 3) // version
+	w.write(0, 1) // no transforms
+	w.write(1, 1) // color cache
+	w.write(vp8lCacheBits, 4)
+	w.write(0, 1) // no meta prefix codes
+
+	var codes [5]*prefixCode
+	for i, histogram := range [][]int{green, red, blue, alpha, dist} {
+		codes[i] = newPrefixCode(histogram, 15)
+		codes[i].writeHeader(&w)
+	}
+
+	for _, token := range tokens {
+		switch {
+		case token.length > 0:
+			symbol, extraBits, extra := prefixEncode(token.length)
+			codes[0].write(&w, 256+symbol)
+			w.write(uint32(extra), extraBits)
+
+			symbol, extraBits, extra = prefixEncode(token.dist)
+			codes[4].write(&w, symbol)
+			w.write(uint32(extra), extraBits)
+
+		case token.cached >= 0:
+			codes[0].write(&w, 256+vp8lLengthCodes+token.cached)
+
+		default:
+			codes[0].write(&w, int(token.argb>>8&0xFF))
+			codes[1].write(&w, int(token.argb>>16&0xFF))
+			codes[2].write(&w, int(token.argb&0xFF))
+			codes[3].write(&w, int(token.argb>>24))
+		}
+	}
+
+	return w.bytes()
+}
+
+// matchLength returns the number of pixels starting at the given position
+// that are equal to the pixels the given distance before them
+func matchLength(pixels []uint32, i int, distance int) int {
+	if i < distance {
+		return 0
+	}
+
+	var length int
+	for i+length < len(pixels) && length < vp8lMaxLength && pixels[i+length] == pixels[i+length-distance] {
+		length++
+	}
+
+	return length
+}
+
+// prefixEncode returns the prefix symbol and the extra bits of a length or
+// distance code
+func prefixEncode(value int) (symbol int, extraBits int, extra int) {
+	if value <= 4 {
+		return value - 1, 0, 0
+	}
+
+	value--
+	highest := bits.Len(uint(value)) - 1
+	second := value >> (highest - 1) & 1
+	extraBits = highest - 1
+	return 2*highest + second, extraBits, value & (1<<extraBits - 1)
+}
+
+// bitWriter writes values with the least significant bit first
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	count int
+}
+
+func (w *bitWriter) write(value uint32, n int) {
+	w.acc |= uint64(value) << w.count
+	w.count += n
+	for w.count >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.count -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.count > 0 {
+		return append(w.buf, byte(w.acc))
+	}
+
+	return w.buf
+}
+
+// prefixCode is a canonical Huffman code, the codes are stored bit reversed
+// since the bitstream is read with the least significant bit first
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+	symbols []int
+}
+
+// newPrefixCode creates a prefix code based on the symbol frequencies, with
+// code lengths of at most the given limit
+func newPrefixCode(histogram []int, limit int) *prefixCode {
+	var code = prefixCode{
+		lengths: huffmanLengths(histogram, limit),
+		codes:   make([]uint32, len(histogram)),
+	}
+
+	for symbol, length := range code.lengths {
+		if length > 0 {
+			code.symbols = append(code.symbols, symbol)
+		}
+	}
+
+	var count [16]int
+	for _, length := range code.lengths {
+		if length > 0 {
+			count[length]++
+		}
+	}
+
+	var next [16]uint32
+	var value uint32
+	for length := 1; length < len(next); length++ {
+		value = (value + uint32(count[length-1])) << 1
+		next[length] = value
+	}
+
+	for symbol, length := range code.lengths {
+		if length > 0 {
+			code.codes[symbol] = bits.Reverse32(next[length]) >> (32 - length)
+			next[length]++
+		}
+	}
+
+	return &code
+}
+
+// writeHeader writes the code lengths of the prefix code, codes with up to
+// two symbols below 256 are written as a simple code
+func (c *prefixCode) writeHeader(w *bitWriter) {
+	switch {
+	case len(c.symbols) == 0:
+		w.write(1, 1) // simple code
+		w.write(0, 1) // one symbol
+		w.write(0, 1) // one bit symbol
+		w.write(0, 1) // symbol zero
+		return
+
+	case len(c.symbols) <= 2 && c.symbols[len(c.symbols)-1] < 256:
+		w.write(1, 1)
+		w.write(uint32(len(c.symbols)-1), 1)
+		w.write(1, 1) // eight bit symbols
+		for _, symbol := range c.symbols {
+			w.write(uint32(symbol), 8)
+		}
+
+		return
+	}
+
+	w.write(0, 1) // normal code
+
+	var histogram = make([]int, 19)
+	for _, length := range c.lengths {
+		histogram[length]++
+	}
+
+	var lengthCode = newPrefixCode(histogram, 7)
+	w.write(uint32(len(vp8lCodeLengthOrder)-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder {
+		w.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+
+	w.write(0, 1) // code lengths of all symbols are written
+	for _, length := range c.lengths {
+		lengthCode.write(w, length)
+	}
+}
+
+// write writes the code of the symbol, a code with only one symbol does not
+// need any bits
+func (c *prefixCode) write(w *bitWriter, symbol int) {
+	if len(c.symbols) > 1 {
+		w.write(c.codes[symbol], c.lengths[symbol])
+	}
+}
+
+// huffmanLengths returns the code lengths of a Huffman code for the given
+// symbol frequencies, the frequencies are reduced until the longest code
+// fits into the limit
+func huffmanLengths(histogram []int, limit int) []int {
+	type node struct {
+		weight      int
+		symbol      int
+		left, right int
+	}
+
+	var lengths = make([]int, len(histogram))
+	var weights = append([]int{}, histogram...)
+	for {
+		var nodes []node
+		for symbol, weight := range weights {
+			if weight > 0 {
+				nodes = append(nodes, node{weight: weight, symbol: symbol, left: -1, right: -1})
+			}
+		}
+
+		switch len(nodes) {
+		case 0:
+			return lengths
+
+		case 1:
+			lengths[nodes[0].symbol] = 1
+			return lengths
+		}
+
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+		// two queues of leaves and combined nodes, which are both sorted by
+		// weight, since combined nodes are created with increasing weights
+		var leafCount = len(nodes)
+		var leaves, combined = 0, leafCount
+		var smallest = func() int {
+			if leaves < leafCount && (combined >= len(nodes) || nodes[leaves].weight <= nodes[combined].weight) {
+				leaves++
+				return leaves - 1
+			}
+
+			combined++
+			return combined - 1
+		}
+
+		for len(nodes) < 2*leafCount-1 {
+			a, b := smallest(), smallest()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		}
+
+		var longest int
+		var depths = make([]int, len(nodes))
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].left >= 0 {
+				depths[nodes[i].left] = depths[i] + 1
+				depths[nodes[i].right] = depths[i] + 1
+				continue
+			}
+
+			lengths[nodes[i].symbol] = depths[i]
+			longest = max(longest, depths[i])
+		}
+
+		if longest <= limit {
+			return lengths
+		}
+
+		for symbol, weight := range weights {
+			if weight > 0 {
+				weights[symbol] = (weight + 1) / 2
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// castVersion is the supported version of the asciinema cast file format
+const castVersion = 2
+
+// CastHeader is the first line of an asciinema cast file, which describes the
+// recorded terminal, see https://docs.asciinema.org/manual/asciicast/v2/
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Theme     *CastTheme        `json:"theme,omitempty"`
+}
+
+// CastTheme is the color theme of the recorded terminal, the palette is a
+// colon separated list of eight or sixteen colors
+type CastTheme struct {
+	Foreground string `json:"fg"`
+	Background string `json:"bg"`
+	Palette    string `json:"palette"`
+}
+
+// WriteCast writes the recording in the asciinema cast file format into the
+// provided writer, the terminal size and timestamp are taken from the
+// recording unless they are set in the header. The header contains the size
+// at the start, later changes of the size are written as resize events.
+func (r *Recording) WriteCast(w io.Writer, header CastHeader) error {
+	cols, rows := r.InitialSize()
+
+	header.Version = castVersion
+	if header.Width == 0 {
+		header.Width = int(cols)
+	}
+
+	if header.Height == 0 {
+		header.Height = int(rows)
+	}
+
+	// The size of the pseudo terminal is unknown in case it was not
+	// attached to an actual terminal, assume the typical default then
+	if header.Width == 0 || header.Height == 0 {
+		header.Width, header.Height = 80, 24
+	}
+
+	r.Lock()
+	if header.Timestamp == 0 && !r.start.IsZero() {
+		header.Timestamp = r.start.Unix()
+	}
+	r.Unlock()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("failed to write cast header: %w", err)
+	}
+
+	// Output is stored as JSON strings, therefore UTF-8 sequences split
+	// across two reads are moved into the next event
+	var resizes = r.Resizes()
+	var writeResizes = func(until time.Duration) error {
+		for len(resizes) > 0 && resizes[0].Time <= until {
+			if err := enc.Encode([]interface{}{
+				castTime(resizes[0].Time),
+				"r",
+				fmt.Sprintf("%dx%d", resizes[0].Cols, resizes[0].Rows),
+			}); err != nil {
+				return fmt.Errorf("failed to write cast event: %w", err)
+			}
+
+			resizes = resizes[1:]
+		}
+
+		return nil
+	}
+
+	var pending []byte
+	for _, event := range r.Events() {
+		if err := writeResizes(event.Time); err != nil {
+			return err
+		}
+
+		data := append(pending, event.Data...)
+		n := completeRunes(data)
+		pending = append([]byte{}, data[n:]...)
+		if n == 0 {
+			continue
+		}
+
+		if err := enc.Encode([]interface{}{
+			castTime(event.Time),
+			"o",
+			string(data[:n]),
+		}); err != nil {
+			return fmt.Errorf("failed to write cast event: %w", err)
+		}
+	}
+
+	if err := writeResizes(math.MaxInt64); err != nil {
+		return err
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// castTime formats the time of an event as seconds with microsecond precision
+func castTime(t time.Duration) json.Number {
+	return json.Number(strconv.FormatFloat(t.Seconds(), 'f', 6, 64))
+}
+
+// ReadCast reads an asciinema cast file into a recording, only output and
+// resize events are used, all other event types are ignored
+func ReadCast(r io.Reader) (*Recording, CastHeader, error) {
+	var header CastHeader
+	var reader = bufio.NewReader(r)
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, header, err
+	}
+
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, header, fmt.Errorf("failed to parse cast header: %w", err)
+	}
+
+	if header.Version != castVersion {
+		return nil, header, fmt.Errorf("unsupported cast file version %d, only version %d is supported", header.Version, castVersion)
+	}
+
+	var recording = &Recording{
+		start: time.Unix(header.Timestamp, 0),
+		cols:  uint16(header.Width),
+		rows:  uint16(header.Height),
+	}
+
+	for number := 2; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, header, err
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var event []interface{}
+			if err := json.Unmarshal(trimmed, &event); err != nil {
+				return nil, header, fmt.Errorf("failed to parse cast event in line %d: %w", number, err)
+			}
+
+			if len(event) != 3 {
+				return nil, header, fmt.Errorf("failed to parse cast event in line %d: expected time, code, and data", number)
+			}
+
+			seconds, okTime := event[0].(float64)
+			code, okCode := event[1].(string)
+			data, okData := event[2].(string)
+			if !okTime || !okCode || !okData {
+				return nil, header, fmt.Errorf("failed to parse cast event in line %d: expected time, code, and data", number)
+			}
+
+			var timestamp = time.Duration(seconds * float64(time.Second))
+			switch code {
+			case "o":
+				recording.events = append(recording.events, Event{Time: timestamp, Data: []byte(data)})
+
+			case "r":
+				var cols, rows uint16
+				if _, err := fmt.Sscanf(data, "%dx%d", &cols, &rows); err == nil {
+					recording.resizeAt(timestamp, cols, rows)
+				}
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	return recording, header, nil
+}
+
+// completeRunes returns the length of the data without a trailing incomplete
+// UTF-8 sequence
+func completeRunes(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+
+			break
+		}
+	}
+
+	return len(data)
+}
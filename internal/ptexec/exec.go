@@ -22,19 +22,51 @@ package ptexec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/mattn/go-isatty"
 	"golang.org/x/term"
+
+	"github.com/homeport/termshot/internal/ansi"
 )
 
+// terminationGracePeriod is the time a command has to exit after it was
+// asked to terminate, before its process group is killed
+const terminationGracePeriod = time.Second
+
+// cleanEnvironment are the environment variables with fixed values in a
+// clean environment, so that the output does not depend on the machine
+var cleanEnvironment = map[string]string{
+	"TERM":      "xterm-256color",
+	"COLORTERM": "truecolor",
+	"HOME":      "/home/termshot",
+	"USER":      "termshot",
+}
+
+// defaultAllowlist are the environment variables kept in a clean environment
+var defaultAllowlist = []string{"PATH", "LANG", "LANGUAGE", "LC_ALL", "LC_CTYPE", "TZ"}
+
+// envVar is an environment variable to be set or unset
+type envVar struct {
+	name  string
+	value string
+	unset bool
+}
+
 // PseudoTerminal defines the setup for a command to be run in a pseudo
 // terminal, e.g. terminal size, or output settings
 type PseudoTerminal struct {
@@ -49,7 +81,30 @@ type PseudoTerminal struct {
 	rows   uint16
 	resize bool
 
-	stdout io.Writer
+	sizeMutex sync.Mutex
+	size      pty.Winsize
+
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    *StderrStyle
+	recording *Recording
+
+	snapshotIdle     time.Duration
+	snapshotDeadline time.Duration
+
+	script *Script
+
+	session *Session
+	echo    func(command string) string
+
+	dir       string
+	env       []envVar
+	cleanEnv  bool
+	allowlist []string
+
+	sandboxed bool
+	fixtures  string
+	box       *sandbox
 }
 
 // New creates a new pseudo terminal builder
@@ -57,6 +112,7 @@ func New() *PseudoTerminal {
 	return &PseudoTerminal{
 		shell:  "/bin/sh",
 		resize: true,
+		stdin:  os.Stdin,
 		stdout: os.Stdout,
 	}
 }
@@ -73,12 +129,117 @@ func (c *PseudoTerminal) Rows(rows uint16) *PseudoTerminal {
 	return c
 }
 
+// Stdin sets the reader to be used for the standard input, which is read
+// until the command exited in case it is a file, any other reader is read
+// until it returns
+func (c *PseudoTerminal) Stdin(stdin io.Reader) *PseudoTerminal {
+	c.stdin = stdin
+	return c
+}
+
 // Stdout sets the writer to be used for the standard output
 func (c *PseudoTerminal) Stdout(stdout io.Writer) *PseudoTerminal {
 	c.stdout = stdout
 	return c
 }
 
+// SeparateStderr configures that the standard error of the command is captured
+// through a separate pipe instead of the pseudo terminal, it is merged into
+// the output using the provided style
+func (c *PseudoTerminal) SeparateStderr(style StderrStyle) *PseudoTerminal {
+	c.stderr = &style
+	return c
+}
+
+// Record sets a recording, which collects the output with timestamps
+func (c *PseudoTerminal) Record(recording *Recording) *PseudoTerminal {
+	c.recording = recording
+	return c
+}
+
+// Size returns the columns and rows of the pseudo terminal the command was
+// run in, in case the terminal was resized it is the last known size
+func (c *PseudoTerminal) Size() (cols uint16, rows uint16) {
+	c.sizeMutex.Lock()
+	defer c.sizeMutex.Unlock()
+	return c.size.Cols, c.size.Rows
+}
+
+// SnapshotIdle configures that the output is taken once the command did not
+// write any output for the given duration, after which the command is
+// terminated, this is meant for commands that do not exit by themselves
+func (c *PseudoTerminal) SnapshotIdle(idle time.Duration) *PseudoTerminal {
+	c.snapshotIdle = idle
+	return c
+}
+
+// SnapshotDeadline configures that the output is taken after the given
+// duration at the latest, after which the command is terminated
+func (c *PseudoTerminal) SnapshotDeadline(deadline time.Duration) *PseudoTerminal {
+	c.snapshotDeadline = deadline
+	return c
+}
+
+// Script sets a script, which provides the input of the command instead of
+// the standard input
+func (c *PseudoTerminal) Script(script *Script) *PseudoTerminal {
+	c.script = script
+	return c
+}
+
+// Session configures a sequence of commands to be run in the same shell
+// instead of a single command, the echo function returns what is shown in
+// front of the output of each command, e.g. a prompt and the command itself
+func (c *PseudoTerminal) Session(session *Session, echo func(command string) string) *PseudoTerminal {
+	c.session = session
+	c.echo = echo
+	return c
+}
+
+// Dir sets the working directory of the command
+func (c *PseudoTerminal) Dir(dir string) *PseudoTerminal {
+	c.dir = dir
+	return c
+}
+
+// Setenv sets an environment variable for the command
+func (c *PseudoTerminal) Setenv(name string, value string) *PseudoTerminal {
+	c.env = append(c.env, envVar{name: name, value: value})
+	return c
+}
+
+// Unsetenv removes an environment variable for the command
+func (c *PseudoTerminal) Unsetenv(name string) *PseudoTerminal {
+	c.env = append(c.env, envVar{name: name, unset: true})
+	return c
+}
+
+// CleanEnv configures that the command does not inherit the environment,
+// except for PATH, the locale settings, and the given variables. The
+// variables TERM, COLORTERM, HOME, and USER are set to fixed values, and
+// COLUMNS and LINES to the size of the pseudo terminal, in case it is set
+// with Cols or Rows, or inherited from the terminal termshot runs in.
+// Variables set with Setenv take precedence.
+func (c *PseudoTerminal) CleanEnv(allow ...string) *PseudoTerminal {
+	c.cleanEnv = true
+	c.allowlist = append(c.allowlist, allow...)
+	return c
+}
+
+// Sandbox configures that the command runs in a throwaway home directory
+// with a fixed path, which is also its working directory, seeded with a copy
+// of the fixtures directory, if any. It implies a clean environment with fixed values for
+// the user name, host name, time zone, and locale, and all files have the
+// same modification time, so that the output does not depend on the machine.
+// The owner of the files and the current time are not pinned. The sandbox is
+// removed once the command exited, commands running in a sandbox at the same
+// time wait for each other.
+func (c *PseudoTerminal) Sandbox(fixtures string) *PseudoTerminal {
+	c.sandboxed = true
+	c.fixtures = fixtures
+	return c
+}
+
 // Command sets the command and arguments to be used
 func (c *PseudoTerminal) Command(name string, args ...string) *PseudoTerminal {
 	c.name = name
@@ -106,119 +267,466 @@ func (c *PseudoTerminal) SetShellOpts(opts []string) *PseudoTerminal {
 
 // Run runs the provided command/script with the given arguments in a pseudo
 // terminal (PTY) so that the behavior is the same if it would be executed
-// in a terminal
-func (c *PseudoTerminal) Run() ([]byte, error) {
-	if c.name == "" {
+// in a terminal, the result contains the output and the exit status
+func (c *PseudoTerminal) Run() (*Result, error) {
+	return c.RunContext(context.Background())
+}
+
+// RunContext runs the command like Run, but terminates the process group of
+// the command once the context is done, in which case the result with the
+// output captured until then is returned together with an error wrapping
+// the context error
+func (c *PseudoTerminal) RunContext(ctx context.Context) (result *Result, err error) {
+	if c.name == "" && c.session == nil {
 		return nil, fmt.Errorf("no command specified")
 	}
 
-	// Convenience hack in case command contains a space, for example in case
-	// typical construct like "foo | grep" are used.
-	if strings.Contains(c.name, " ") {
-		shellCmd := strings.Join(append(
-			[]string{c.name},
-			c.args...,
-		), " ")
-		
-		// If shell config is specified, source it first
-		if c.shellConfig != "" {
-			shellCmd = fmt.Sprintf("source %s && %s", c.shellConfig, shellCmd)
+	switch {
+	case c.session != nil:
+		// The commands of a session are run by one shell, one after another
+		syntax := syntaxOf(c.shell)
+		if syntax.eval == nil {
+			return nil, fmt.Errorf("sessions are not supported with shell %s", c.shell)
 		}
-		
-		c.args = []string{"-c", shellCmd}
-		
-		// Add any shell options
-		if len(c.shellOpts) > 0 {
-			c.args = append(c.shellOpts, c.args...)
-		}
-		
+
+		c.args = append(append([]string{}, c.shellOpts...), "-c", c.sessionScript(syntax))
 		c.name = c.shell
-	} else if c.shellConfig != "" {
-		// If shell config is provided but command doesn't have spaces,
-		// still wrap it to source the config
-		shellCmd := strings.Join(append(
-			[]string{c.name},
-			c.args...,
-		), " ")
-		shellCmd = fmt.Sprintf("source %s && %s", c.shellConfig, shellCmd)
-		
-		c.args = []string{"-c", shellCmd}
-		
-		if len(c.shellOpts) > 0 {
-			c.args = append(c.shellOpts, c.args...)
-		}
-		
+
+	case strings.Contains(c.name, " ") || c.shellConfig != "":
+		// Convenience hack in case command contains a space, for example in
+		// case typical construct like "foo | grep" are used, or in case a
+		// shell config needs to be sourced first, the command is run by the
+		// shell.
+		shellCmd := c.shellCommand()
+
+		// Add any shell options
+		c.args = append(append([]string{}, c.shellOpts...), "-c", shellCmd)
 		c.name = c.shell
 	}
 
-	// Set RAW mode for Stdin
-	if isTerminal(os.Stdin) {
-		oldState, rawErr := term.MakeRaw(int(os.Stdin.Fd()))
+	// Set RAW mode for Stdin, unless the input is provided by a script
+	if stdin, ok := c.stdin.(*os.File); ok && c.script == nil && isTerminal(stdin) {
+		oldState, rawErr := term.MakeRaw(int(stdin.Fd()))
 		if rawErr != nil {
 			return nil, fmt.Errorf("failed to enable RAW mode for Stdin: %w", rawErr)
 		}
 
 		// And make sure to restore the original mode eventually
-		defer func() { _ = term.Restore(int(os.Stdin.Fd()), oldState) }()
+		defer func() { _ = term.Restore(int(stdin.Fd()), oldState) }()
 	}
 
-	// collect all errors along the way
-	var errors = []error{}
+	if c.recording != nil {
+		c.recording.begin()
+	}
 
 	// #nosec G204 -- since this is exactly what we want, arbitrary commands
-	pt, err := c.pseudoTerminal(exec.Command(c.name, c.args...))
+	cmd := exec.Command(c.name, c.args...)
+	cmd.Dir = c.dir
+
+	if c.sandboxed {
+		box, boxErr := newSandbox(c.fixtures)
+		if boxErr != nil {
+			return nil, boxErr
+		}
+
+		// Removed after all background tasks are done, since deferred
+		// functions run in reverse order
+		defer func() {
+			if removeErr := box.remove(); removeErr != nil && err == nil {
+				err = removeErr
+			}
+		}()
+
+		c.box = box
+		cmd.Dir = box.dir(c.dir)
+	}
+
+	var stderrRead, stderrWrite *os.File
+	if c.stderr != nil {
+		var pipeErr error
+		if stderrRead, stderrWrite, pipeErr = os.Pipe(); pipeErr != nil {
+			return nil, fmt.Errorf("failed to create pipe for standard error: %w", pipeErr)
+		}
+
+		defer func() { _ = stderrRead.Close() }()
+		cmd.Stderr = stderrWrite
+	}
+
+	start := time.Now()
+	pt, err := c.pseudoTerminal(cmd)
+	if stderrWrite != nil {
+		_ = stderrWrite.Close()
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
+	defer func() { _ = pt.Close() }()
+
+	// The result is complete once the command exited, closing the channel
+	// makes the exit status available to all readers
+	result = &Result{}
+	var exited = make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+
+		result.Duration = time.Since(start)
+		result.ExitCode = cmd.ProcessState.ExitCode()
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = status.Signal()
+		}
+
+		close(exited)
+	}()
+
+	var out = &output{recording: c.recording, last: time.Now()}
+
+	// Taking the snapshot stops capturing output and terminates the command
+	var terminated atomic.Bool
+	var snapshot = sync.OnceFunc(func() {
+		terminated.Store(true)
+		out.stop()
+		terminate(cmd, exited)
+	})
+
+	// All goroutines running alongside the command are stopped and joined
+	// before returning, their errors are part of the returned error
+	var background backgroundErrors
+	var wg sync.WaitGroup
+	var stops, cleanups []func()
+	defer func() {
+		select {
+		case <-exited:
+		default:
+			snapshot()
+			<-exited
+		}
+
+		for _, stop := range stops {
+			stop()
+		}
+
+		wg.Wait()
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+
+		if err == nil {
+			err = background.err()
+		}
+	}()
+
+	c.updateSize(pt)
+
 	// Support terminal resizing
 	if c.resize && isTerminal(os.Stdin) {
 		ch := make(chan os.Signal, 1)
 		signal.Notify(ch, syscall.SIGWINCH)
+
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			for range ch {
 				if ptyErr := pty.InheritSize(os.Stdin, pt); ptyErr != nil {
-					errors = append(errors, fmt.Errorf("error resizing PTY: %w", ptyErr))
+					background.add(fmt.Errorf("error resizing PTY: %w", ptyErr))
 				}
+
+				c.updateSize(pt)
 			}
 		}()
 
 		ch <- syscall.SIGWINCH
-		defer func() {
+		stops = append(stops, func() {
 			signal.Stop(ch)
 			close(ch)
+		})
+	}
+
+	if c.snapshotIdle > 0 || c.snapshotDeadline > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.awaitSnapshot(out, exited, snapshot)
 		}()
 	}
 
+	var cancelled atomic.Bool
+	wg.Add(1)
 	go func() {
-		defer func() { _ = pt.Close() }()
-		_, copyErr := io.Copy(pt, os.Stdin)
-		if copyErr != nil {
-			errors = append(errors, copyErr)
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			cancelled.Store(true)
+			snapshot()
+
+		case <-exited:
 		}
 	}()
 
-	var buf bytes.Buffer
-	if err = copy(io.MultiWriter(c.stdout, &buf), pt); err != nil {
+	var scriptDone = make(chan error, 1)
+	switch {
+	case c.script != nil:
+		// The output is processed by a virtual terminal, so that the script
+		// can wait for text to be shown on the screen
+		cols, rows := c.Size()
+		out.terminal = ansi.NewVirtualTerminal(int(cols), int(rows))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scriptErr := c.script.run(pt, out, exited, snapshot)
+			if scriptErr != nil {
+				snapshot()
+			}
+
+			scriptDone <- scriptErr
+		}()
+
+	default:
+		scriptDone <- nil
+
+		// A file like the standard input is read until the command exited,
+		// since the read would block otherwise
+		var stdin = c.stdin
+		if file, ok := c.stdin.(*os.File); ok {
+			in, inputErr := newInput(file)
+			if inputErr != nil {
+				return nil, inputErr
+			}
+
+			stdin = in
+			stops = append(stops, in.cancel)
+			cleanups = append(cleanups, in.close)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, copyErr := io.Copy(pt, stdin)
+			switch {
+			case errors.Is(copyErr, errInputCancelled):
+				return
+
+			case copyErr != nil:
+				background.add(fmt.Errorf("failed to copy input: %w", copyErr))
+				return
+			}
+
+			// Signal the end of input with the EOF character instead of
+			// closing the pseudo terminal, which would cut off the output
+			_, _ = pt.Write([]byte{4})
+		}()
+	}
+
+	// Both the pseudo terminal and the separate standard error end up in the
+	// same output, which requires the writes to be serialized
+	var merged = &lockedWriter{w: io.MultiWriter(c.stdout, out)}
+	var dst io.Writer = merged
+
+	var stdout bytes.Buffer
+	var stderr *stderrWriter
+	var stderrDone = make(chan error, 1)
+	switch {
+	case c.stderr != nil:
+		dst = io.MultiWriter(merged, &stdout)
+		stderr = &stderrWriter{out: merged, style: *c.stderr}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, copyErr := io.Copy(stderr, stderrRead)
+			stderrDone <- copyErr
+		}()
+
+	default:
+		stderrDone <- nil
+	}
+
+	// The markers of a session are replaced with the echo of the commands
+	var session *sessionWriter
+	if c.session != nil {
+		session = &sessionWriter{out: dst, commands: c.session.commands, echo: c.echo}
+		dst = session
+	}
+
+	if err = copy(dst, pt); err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		if err = session.flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	<-exited
+	if err := <-stderrDone; err != nil {
+		return nil, fmt.Errorf("failed to read standard error: %w", err)
+	}
+
+	result.Output = out.bytes()
+	result.Terminated = terminated.Load()
+	if stderr != nil {
+		result.Stdout = stdout.Bytes()
+		result.Stderr = stderr.raw.Bytes()
+	}
+
+	if cancelled.Load() {
+		return result, fmt.Errorf("command was terminated: %w", context.Cause(ctx))
+	}
+
+	if err := <-scriptDone; err != nil {
 		return nil, err
 	}
 
-	if len(errors) > 0 {
-		fmt.Fprintf(os.Stderr, "issues in background tasks:\n")
-		for _, err := range errors {
-			fmt.Fprintf(os.Stderr, "- %v\n", err.Error())
+	return result, nil
+}
+
+// backgroundErrors collects the errors of the goroutines running alongside
+// the command
+type backgroundErrors struct {
+	sync.Mutex
+	errs []error
+}
+
+func (b *backgroundErrors) add(err error) {
+	b.Lock()
+	defer b.Unlock()
+	b.errs = append(b.errs, err)
+}
+
+func (b *backgroundErrors) err() error {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("issues in background tasks: %w", errors.Join(b.errs...))
+}
+
+// awaitSnapshot waits until the command is idle or the deadline passed, and
+// takes the snapshot
+func (c *PseudoTerminal) awaitSnapshot(out *output, exited <-chan struct{}, snapshot func()) {
+	var start = time.Now()
+	var ticker = time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			return
+
+		case now := <-ticker.C:
+			idle := c.snapshotIdle > 0 && now.Sub(out.lastWrite()) >= c.snapshotIdle
+			expired := c.snapshotDeadline > 0 && now.Sub(start) >= c.snapshotDeadline
+			if idle || expired {
+				snapshot()
+				return
+			}
 		}
 	}
+}
+
+// terminate asks the process group of the command to terminate, and kills
+// the process group in case the command did not exit within the grace period
+// or processes of the group are left over
+func terminate(cmd *exec.Cmd, exited <-chan struct{}) {
+	// the command runs in its own session, so that its process group id is
+	// the same as its process id
+	pgid := cmd.Process.Pid
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	select {
+	case <-exited:
+	case <-time.After(terminationGracePeriod):
+	}
 
-	return buf.Bytes(), nil
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// output collects the output of the command until it is stopped, and keeps
+// track of the time of the last output
+type output struct {
+	sync.Mutex
+	buf       bytes.Buffer
+	recording *Recording
+	terminal  *ansi.VirtualTerminal
+	last      time.Time
+	stopped   bool
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.stopped {
+		return len(p), nil
+	}
+
+	o.last = time.Now()
+	if o.recording != nil {
+		_, _ = o.recording.Write(p)
+	}
+
+	if o.terminal != nil {
+		_, _ = o.terminal.Write(p)
+	}
+
+	return o.buf.Write(p)
+}
+
+func (o *output) lastWrite() time.Time {
+	o.Lock()
+	defer o.Unlock()
+	return o.last
+}
+
+// shows returns whether the text is shown on the screen of the terminal
+func (o *output) shows(text string) bool {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.terminal == nil {
+		return false
+	}
+
+	return strings.Contains(plain(o.terminal.Content()), text)
+}
+
+// stop ignores all further output
+func (o *output) stop() {
+	o.Lock()
+	defer o.Unlock()
+	o.stopped = true
+}
+
+func (o *output) bytes() []byte {
+	o.Lock()
+	defer o.Unlock()
+	return o.buf.Bytes()
 }
 
 func (c *PseudoTerminal) pseudoTerminal(cmd *exec.Cmd) (*os.File, error) {
 	if c.cols == 0 && c.rows == 0 {
+		// the size is inherited from the terminal once started, if any
+		var size *pty.Winsize
+		if isTerminal(os.Stdin) {
+			size, _ = pty.GetsizeFull(os.Stdin)
+		}
+
+		cmd.Env = c.environment(size)
 		return pty.Start(cmd)
 	}
 
-	size, err := pty.GetsizeFull(os.Stdout)
+	// With both rows and columns configured, the size of the terminal is
+	// not required
+	var err error
+	size := &pty.Winsize{Rows: c.rows, Cols: c.cols}
+	if c.rows == 0 || c.cols == 0 {
+		size, err = pty.GetsizeFull(os.Stdout)
+	}
+
 	if err != nil {
 		// Obtaining terminal size is prone to error in CI systems, e.g. in
 		// GitHub Action setup or similar, so only fail if CI is not set
@@ -244,9 +752,87 @@ func (c *PseudoTerminal) pseudoTerminal(cmd *exec.Cmd) (*os.File, error) {
 	// With fixed rows/cols, terminal resizing support is not useful
 	c.resize = false
 
+	cmd.Env = c.environment(size)
 	return pty.StartWithSize(cmd, size)
 }
 
+// environment returns the environment variables of the command, or nil in
+// case the environment of termshot is inherited as-is
+func (c *PseudoTerminal) environment(size *pty.Winsize) []string {
+	var clean = c.cleanEnv || c.box != nil
+	if !clean && len(c.env) == 0 {
+		return nil
+	}
+
+	var allowed = map[string]bool{}
+	for _, name := range append(defaultAllowlist, c.allowlist...) {
+		allowed[name] = true
+	}
+
+	var env = map[string]string{}
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if !clean || allowed[name] {
+			env[name] = value
+		}
+	}
+
+	if clean {
+		for name, value := range cleanEnvironment {
+			env[name] = value
+		}
+
+		if size != nil && size.Cols > 0 && size.Rows > 0 {
+			env["COLUMNS"] = strconv.Itoa(int(size.Cols))
+			env["LINES"] = strconv.Itoa(int(size.Rows))
+		}
+	}
+
+	if c.box != nil {
+		for name, value := range sandboxEnvironment {
+			env[name] = value
+		}
+
+		env["HOME"] = c.box.home
+		env["TMPDIR"] = c.box.tmp
+	}
+
+	for _, variable := range c.env {
+		switch {
+		case variable.unset:
+			delete(env, variable.name)
+
+		default:
+			env[variable.name] = variable.value
+		}
+	}
+
+	var result = make([]string, 0, len(env))
+	for name, value := range env {
+		result = append(result, name+"="+value)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// updateSize stores the current size of the pseudo terminal, and in the
+// recording in case one is configured
+func (c *PseudoTerminal) updateSize(pt *os.File) {
+	size, err := pty.GetsizeFull(pt)
+	if err != nil {
+		return
+	}
+
+	c.sizeMutex.Lock()
+	c.size = *size
+	c.sizeMutex.Unlock()
+
+	if c.recording != nil {
+		c.recording.resize(size.Cols, size.Rows)
+	}
+}
+
 func copy(dst io.Writer, src io.Reader) error {
 	_, err := io.Copy(dst, src)
 	if err != nil {
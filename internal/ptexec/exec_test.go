@@ -21,6 +21,19 @@
 package ptexec_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing/iotest"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -30,27 +43,509 @@ import (
 var _ = Describe("Pseudo Terminal Execute Suite", func() {
 	Context("running commands in pseudo terminal", func() {
 		It("should run a command just fine", func() {
-			out, err := New().Stdout(GinkgoWriter).
+			result, err := New().Stdout(GinkgoWriter).
 				Command("echo", "hello").
 				Run()
 
 			Expect(err).ToNot(HaveOccurred())
-			Expect(trimmed(out)).To(Equal("hello"))
+			Expect(trimmed(result.Output)).To(Equal("hello"))
 		})
 
 		It("should run a script just fine", func() {
-			out, err := New().Stdout(GinkgoWriter).
+			result, err := New().Stdout(GinkgoWriter).
 				Command("echo hello").
 				Run()
 
 			Expect(err).ToNot(HaveOccurred())
-			Expect(trimmed(out)).To(Equal("hello"))
+			Expect(trimmed(result.Output)).To(Equal("hello"))
+		})
+
+		It("should report the exit status of the command", func() {
+			result, err := New().Stdout(GinkgoWriter).Command("echo failing; exit 3").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("failing"))
+			Expect(result.Success()).To(BeFalse())
+			Expect(result.ExitCode).To(Equal(3))
+			Expect(result.Status()).To(Equal("exit 3"))
+			Expect(result.Duration).To(BeNumerically(">", 0))
+
+			result, err = New().Stdout(GinkgoWriter).Command("kill -KILL $$").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ExitCode).To(Equal(-1))
+			Expect(result.Signal).To(Equal(syscall.SIGKILL))
+			Expect(result.Status()).To(Equal("killed by SIGKILL"))
 		})
 
 		It("should run with fixed terminal size", func() {
-			out, err := New().Stdout(GinkgoWriter).Cols(40).Rows(12).Command("stty", "size").Run()
+			result, err := New().Stdout(GinkgoWriter).Cols(40).Rows(12).Command("stty", "size").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("12 40"))
+		})
+
+		It("should report the size of the pseudo terminal", func() {
+			pt := New().Stdout(GinkgoWriter).Command("stty", "size")
+			result, err := pt.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			cols, rows := pt.Size()
+			Expect(trimmed(result.Output)).To(Equal(fmt.Sprintf("%d %d", rows, cols)))
+		})
+
+		It("should take the output once the command is idle and terminate it", func() {
+			start := time.Now()
+			result, err := New().Stdout(GinkgoWriter).
+				SnapshotIdle(200 * time.Millisecond).
+				Command("echo hello; sleep 30").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("hello"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should take the output at the deadline and terminate the command", func() {
+			start := time.Now()
+			result, err := New().Stdout(GinkgoWriter).
+				SnapshotDeadline(300 * time.Millisecond).
+				Command("while true; do echo tick; sleep 0.05; done").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(HavePrefix("tick"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should terminate the command once the context is done", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			result, err := New().Stdout(GinkgoWriter).
+				Command("echo partial; sleep 30").
+				RunContext(ctx)
+
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(trimmed(result.Output)).To(Equal("partial"))
+			Expect(result.Terminated).To(BeTrue())
+			Expect(result.Signal).To(Equal(syscall.SIGTERM))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should kill commands that ignore the termination signal", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			result, err := New().Stdout(GinkgoWriter).
+				Command(`trap "" TERM; echo stubborn; sleep 30`).
+				RunContext(ctx)
+
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(trimmed(result.Output)).To(Equal("stubborn"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should record the output with timestamps", func() {
+			recording := NewRecording()
+			result, err := New().Stdout(GinkgoWriter).
+				Record(recording).
+				Command("echo hello").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recording.Events()).ToNot(BeEmpty())
+
+			frames := recording.Frames(10, time.Second)
+			Expect(frames).ToNot(BeEmpty())
+			Expect(frames[len(frames)-1].Data).To(Equal(result.Output))
+		})
+	})
+
+	Context("running commands with a shell", func() {
+		var config string
+
+		BeforeEach(func() {
+			config = filepath.Join(GinkgoT().TempDir(), "with space", "it's.sh")
+			Expect(os.MkdirAll(filepath.Dir(config), 0755)).To(Succeed())
+			Expect(os.WriteFile(config, []byte("GREETING='hello there'\n"), 0644)).To(Succeed())
+		})
+
+		It("should quote the arguments of scripts", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				Command("printf '%s|'", "it's", "$HOME", "a  b", `"quoted"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal(`it's|$HOME|a  b|"quoted"|`))
+		})
+
+		It("should quote the shell config and the command", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				SetShellConfig(config).
+				Command(`echo "$GREETING"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("hello there"))
+
+			result, err = New().Stdout(GinkgoWriter).
+				SetShellConfig(config).
+				Command("printf", "%s|", "it's", "$GREETING").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("it's|$GREETING|"))
+		})
+
+		It("should return the quoted command line", func() {
+			Expect(CommandLine("printf", "%s|", "it's", "a  b", "-n")).To(Equal(`printf '%s|' 'it'\''s' 'a  b' -n`))
+			Expect(CommandLine("ls -l | wc -l")).To(Equal("ls -l | wc -l"))
+		})
+
+		It("should use the syntax of fish", func() {
+			fish, err := exec.LookPath("fish")
+			if err != nil {
+				Skip("fish is not installed")
+			}
+
+			Expect(os.WriteFile(config, []byte("set GREETING 'hello there'\n"), 0644)).To(Succeed())
+			result, err := New().Stdout(GinkgoWriter).
+				SetShell(fish).
+				SetShellConfig(config).
+				Command("printf", "%s|", "it's", `back\slash`, "$GREETING").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal(`it's|back\slash|$GREETING|`))
+		})
+	})
+
+	Context("running commands in a controlled environment", func() {
+		BeforeEach(func() {
+			Expect(os.Setenv("TERMSHOT_SECRET", "token")).To(Succeed())
+			DeferCleanup(os.Unsetenv, "TERMSHOT_SECRET")
+		})
+
+		It("should run the command in the working directory", func() {
+			dir := GinkgoT().TempDir()
+			result, err := New().Stdout(GinkgoWriter).Dir(dir).Command("pwd").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal(dir))
+		})
+
+		It("should set and unset environment variables", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				Setenv("FOO", "bar").
+				Unsetenv("TERMSHOT_SECRET").
+				Command(`echo "$FOO ${TERMSHOT_SECRET:-unset}"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("bar unset"))
+		})
+
+		It("should start from a clean environment with fixed values", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				CleanEnv().
+				Setenv("USER", "demo").
+				Command(`echo "$TERM $COLORTERM $HOME $USER ${TERMSHOT_SECRET:-unset}"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("xterm-256color truecolor /home/termshot demo unset"))
+
+			result, err = New().Stdout(GinkgoWriter).
+				CleanEnv("TERMSHOT_SECRET").
+				Command(`echo "$TERMSHOT_SECRET"`).
+				Run()
+
 			Expect(err).ToNot(HaveOccurred())
-			Expect(trimmed(out)).To(Equal("12 40"))
+			Expect(trimmed(result.Output)).To(Equal("token"))
+		})
+
+		It("should run the command in a throwaway sandbox seeded with fixtures", func() {
+			fixtures := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(fixtures, "hello.txt"), []byte("hello"), 0644)).To(Succeed())
+
+			result, err := New().Stdout(GinkgoWriter).
+				Sandbox(fixtures).
+				Command(`cat hello.txt; echo; echo "$USER $HOSTNAME $TZ ${TERMSHOT_SECRET:-unset}"; ls -l hello.txt; echo "$HOME"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+
+			lines := strings.Split(trimmed(result.Output), "\r\n")
+			Expect(lines).To(HaveLen(4))
+			Expect(lines[0]).To(Equal("hello"))
+			Expect(lines[1]).To(Equal("termshot termshot UTC unset"))
+			Expect(lines[2]).To(ContainSubstring(" 2000 hello.txt"))
+
+			// the sandbox is removed, but the fixtures are kept
+			Expect(lines[3]).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(fixtures, "hello.txt")).To(BeAnExistingFile())
+		})
+
+		It("should remove the sandbox with read-only directories", func() {
+			fixtures := GinkgoT().TempDir()
+			Expect(os.Mkdir(filepath.Join(fixtures, "readonly"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(fixtures, "readonly", "hello.txt"), []byte("hello"), 0644)).To(Succeed())
+			Expect(os.Chmod(filepath.Join(fixtures, "readonly"), 0555)).To(Succeed())
+			DeferCleanup(os.Chmod, filepath.Join(fixtures, "readonly"), os.FileMode(0755))
+
+			result, err := New().Stdout(GinkgoWriter).
+				Sandbox(fixtures).
+				Command(`mkdir -p locked/inner && chmod 0500 locked; dirname "$HOME"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("/tmp/termshot-sandbox"))
+			Expect(trimmed(result.Output)).ToNot(BeAnExistingFile())
+		})
+
+		It("should show the same output for the same command in a sandbox", func() {
+			fixtures := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(fixtures, "hello.txt"), []byte("hello"), 0644)).To(Succeed())
+
+			run := func() string {
+				result, err := New().Stdout(GinkgoWriter).
+					Cols(80).
+					Rows(24).
+					Sandbox(fixtures).
+					Command(`pwd; echo "$HOME $TMPDIR"; ls -la ~`).
+					Run()
+
+				Expect(err).ToNot(HaveOccurred())
+				return string(result.Output)
+			}
+
+			first := run()
+			Expect(first).To(ContainSubstring("/tmp/termshot-sandbox/home\r\n"))
+			Expect(run()).To(Equal(first))
+		})
+	})
+
+	Context("running commands with background tasks", func() {
+		It("should pass the input from a reader and signal its end", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				Stdin(strings.NewReader("foobar\n")).
+				Command("cat").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(result.Output)).To(ContainSubstring("foobar"))
+		})
+
+		It("should return while the input is still open and stop all goroutines", func() {
+			before := runtime.NumGoroutine()
+
+			r, w, err := os.Pipe()
+			Expect(err).ToNot(HaveOccurred())
+			defer func() { _ = r.Close(); _ = w.Close() }()
+
+			result, err := New().Stdout(GinkgoWriter).Stdin(r).Command("echo", "hello").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("hello"))
+			Eventually(runtime.NumGoroutine).Should(BeNumerically("<=", before))
+		})
+
+		It("should return errors of background tasks", func() {
+			failure := fmt.Errorf("input failure")
+			_, err := New().Stdout(GinkgoWriter).
+				Stdin(iotest.ErrReader(failure)).
+				Command("sleep", "0.1").
+				Run()
+
+			Expect(err).To(MatchError(ContainSubstring("failed to copy input")))
+			Expect(errors.Is(err, failure)).To(BeTrue())
+		})
+	})
+
+	Context("running commands with separate standard error", func() {
+		It("should capture the standard error separately and merge it styled", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				SeparateStderr(StderrStyle{Color: "\x1b[31m", Gutter: "| "}).
+				Command("echo out; sleep 0.1; echo err >&2; sleep 0.1; echo done").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(result.Stdout)).To(Equal("out\r\ndone\r\n"))
+			Expect(string(result.Stderr)).To(Equal("err\n"))
+			Expect(string(result.Output)).To(Equal("out\r\n| \x1b[31merr\x1b[0m\r\ndone\r\n"))
+		})
+
+		It("should keep the standard error in the pseudo terminal by default", func() {
+			result, err := New().Stdout(GinkgoWriter).Command("echo err >&2").Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("err"))
+			Expect(result.Stderr).To(BeNil())
+		})
+	})
+
+	Context("running commands in a session", func() {
+		var echo = func(command string) string { return "$ " + command + "\r\n" }
+
+		It("should run all commands in the same shell and echo them", func() {
+			session, err := ParseSession(strings.NewReader("# setup\ncd /tmp\nexport FOO=bar\n\npwd\necho $FOO \\\nbaz\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := New().Stdout(GinkgoWriter).Session(session, echo).Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal(strings.Join([]string{
+				"$ cd /tmp",
+				"$ export FOO=bar",
+				"$ pwd",
+				"/tmp",
+				"$ echo $FOO baz",
+				"bar baz",
+			}, "\r\n")))
+		})
+
+		It("should continue after a command with a syntax error", func() {
+			session, err := ParseSession(strings.NewReader("if\necho after\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := New().Stdout(GinkgoWriter).Session(session, echo).Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(result.Output)).To(HaveSuffix("$ echo after\r\nafter\r\n"))
+			Expect(result.Success()).To(BeTrue())
+		})
+
+		It("should fail to parse a session without commands", func() {
+			_, err := ParseSession(strings.NewReader("# nothing\n\n"))
+			Expect(err).To(MatchError("no commands in session"))
+		})
+	})
+
+	Context("running commands with a script", func() {
+		script := func(text string) *Script {
+			script, err := ParseScript(strings.NewReader(text))
+			Expect(err).ToNot(HaveOccurred())
+			return script
+		}
+
+		It("should send text and keys as input", func() {
+			result, err := New().Stdout(GinkgoWriter).
+				Script(script("# answer the question\nsend world\nkey Enter\n")).
+				Command(`read -r name; echo "hello $name"`).
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(result.Output)).To(ContainSubstring("hello world"))
+		})
+
+		It("should wait for text and take a snapshot", func() {
+			start := time.Now()
+			result, err := New().Stdout(GinkgoWriter).
+				Script(script("wait ready\nsnapshot\n")).
+				Command("sleep 0.2; echo ready; sleep 30").
+				Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed(result.Output)).To(Equal("ready"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should fail when the text is not shown in time", func() {
+			_, err := New().Stdout(GinkgoWriter).
+				Script(script(`wait "never" 200ms`)).
+				Command("sleep", "30").
+				Run()
+
+			Expect(err).To(MatchError(ContainSubstring("failed to run script line 1: timed out after 200ms")))
+		})
+
+		It("should fail to parse invalid scripts", func() {
+			_, err := ParseScript(strings.NewReader("send foo\nkey Hyper-X\n"))
+			Expect(err).To(MatchError(`failed to parse script line 2: unsupported key "Hyper-X"`))
+
+			_, err = ParseScript(strings.NewReader("type foo"))
+			Expect(err).To(MatchError(ContainSubstring(`unknown step "type"`)))
+		})
+	})
+
+	Context("recording in asciinema cast format", func() {
+		It("should write and read a cast file", func() {
+			recording := NewRecording()
+			_, _ = recording.Write([]byte("foo\r\n"))
+			_, _ = recording.Write([]byte{'b', 0xC3})
+			_, _ = recording.Write([]byte{0xBC, 'r', '\r', '\n'})
+
+			var buf bytes.Buffer
+			Expect(recording.WriteCast(&buf, CastHeader{Width: 40, Height: 12})).To(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(4))
+			Expect(lines[0]).To(HavePrefix(`{"version":2,"width":40,"height":12,`))
+			Expect(lines[2]).To(HaveSuffix(`"o","b"]`))
+			Expect(lines[3]).To(HaveSuffix(`"o","ür\r\n"]`))
+
+			read, header, err := ReadCast(&buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Width).To(Equal(40))
+			cols, rows := read.Size()
+			Expect(cols).To(BeEquivalentTo(40))
+			Expect(rows).To(BeEquivalentTo(12))
+			Expect(string(read.Output(0))).To(Equal("foo\r\nbür\r\n"))
+		})
+
+		It("should return the output at a given point in time", func() {
+			read, _, err := ReadCast(strings.NewReader(`{"version": 2, "width": 80, "height": 24}
+[0.5, "o", "foo\r\n"]
+[1.0, "i", "ignored"]
+[1.5, "o", "bar\r\n"]
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(read.Output(time.Second))).To(Equal("foo\r\n"))
+			Expect(string(read.Output(0))).To(Equal("foo\r\nbar\r\n"))
+		})
+
+		It("should keep size changes as resize events", func() {
+			read, header, err := ReadCast(strings.NewReader(`{"version": 2, "width": 80, "height": 24}
+[0.5, "o", "foo\r\n"]
+[1.0, "r", "100x30"]
+[1.5, "o", "bar\r\n"]
+[2.0, "r", "120x40"]
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Width).To(Equal(80))
+
+			cols, rows := read.Size()
+			Expect(cols).To(BeEquivalentTo(120))
+			Expect(rows).To(BeEquivalentTo(40))
+
+			var buf bytes.Buffer
+			Expect(read.WriteCast(&buf, CastHeader{})).To(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(5))
+			Expect(lines[0]).To(Equal(`{"version":2,"width":80,"height":24}`))
+			Expect(lines[1]).To(Equal(`[0.500000,"o","foo\r\n"]`))
+			Expect(lines[2]).To(Equal(`[1.000000,"r","100x30"]`))
+			Expect(lines[3]).To(Equal(`[1.500000,"o","bar\r\n"]`))
+			Expect(lines[4]).To(Equal(`[2.000000,"r","120x40"]`))
+		})
+
+		It("should return the size at a given point in time", func() {
+			read, _, err := ReadCast(strings.NewReader(`{"version": 2, "width": 80, "height": 24}
+[0.5, "o", "foo\r\n"]
+[1.0, "r", "100x30"]
+[2.0, "r", "120x40"]
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			cols, rows := read.SizeAt(500 * time.Millisecond)
+			Expect([]uint16{cols, rows}).To(Equal([]uint16{80, 24}))
+
+			cols, rows = read.SizeAt(1500 * time.Millisecond)
+			Expect([]uint16{cols, rows}).To(Equal([]uint16{100, 30}))
+
+			cols, rows = read.SizeAt(0)
+			Expect([]uint16{cols, rows}).To(Equal([]uint16{120, 40}))
+		})
+
+		It("should fail for unsupported versions", func() {
+			_, _, err := ReadCast(strings.NewReader(`{"version": 1, "width": 80, "height": 24, "stdout": []}`))
+			Expect(err).To(MatchError(ContainSubstring("unsupported cast file version 1")))
 		})
 	})
 })
@@ -0,0 +1,93 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// errInputCancelled is returned by an input once it was cancelled
+var errInputCancelled = errors.New("input cancelled")
+
+// input reads from a file until it is cancelled, since a blocking read, for
+// example of the standard input, cannot be interrupted otherwise
+type input struct {
+	file        *os.File
+	fd          int
+	cancelRead  *os.File
+	cancelWrite *os.File
+	cancelFd    int
+}
+
+func newInput(file *os.File) (*input, error) {
+	cancelRead, cancelWrite, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe for input: %w", err)
+	}
+
+	return &input{
+		file:        file,
+		fd:          int(file.Fd()),
+		cancelRead:  cancelRead,
+		cancelWrite: cancelWrite,
+		cancelFd:    int(cancelRead.Fd()),
+	}, nil
+}
+
+// Read waits until either the file can be read, or the input is cancelled
+func (in *input) Read(p []byte) (int, error) {
+	fd, cancelFd := in.fd, in.cancelFd
+	for {
+		var fds unix.FdSet
+		fds.Set(fd)
+		fds.Set(cancelFd)
+
+		if _, err := unix.Select(max(fd, cancelFd)+1, &fds, nil, nil, nil); err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+
+			return 0, err
+		}
+
+		if fds.IsSet(cancelFd) {
+			return 0, errInputCancelled
+		}
+
+		if fds.IsSet(fd) {
+			return in.file.Read(p)
+		}
+	}
+}
+
+// cancel makes pending and future reads return, the pipe becomes readable
+// once its write end is closed
+func (in *input) cancel() {
+	_ = in.cancelWrite.Close()
+}
+
+func (in *input) close() {
+	_ = in.cancelRead.Close()
+}
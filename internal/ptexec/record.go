@@ -0,0 +1,295 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// finalFrameHold is the duration the last frame of a recording is shown
+const finalFrameHold = 2 * time.Second
+
+// Event is a chunk of output read from the pseudo terminal together with the
+// time since the start of the command
+type Event struct {
+	Time time.Duration
+	Data []byte
+}
+
+// Resize is a change of the terminal size during the recording together with
+// the time since the start of the command
+type Resize struct {
+	Time time.Duration
+	Cols uint16
+	Rows uint16
+}
+
+// Frame is the accumulated output up to a point in time of the recording,
+// which is shown for the given delay
+type Frame struct {
+	Data  []byte
+	Delay time.Duration
+}
+
+// Recording collects the output of the pseudo terminal with timestamps
+type Recording struct {
+	sync.Mutex
+	start  time.Time
+	events []Event
+
+	cols uint16
+	rows uint16
+
+	// initial size of the terminal before the first resize
+	initialCols uint16
+	initialRows uint16
+	resizes     []Resize
+}
+
+// NewRecording creates a new empty recording
+func NewRecording() *Recording {
+	return &Recording{}
+}
+
+// begin sets the reference time all events are relative to
+func (r *Recording) begin() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.start = time.Now()
+}
+
+// Write adds the provided data as a new event to the recording
+func (r *Recording) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+
+	r.events = append(r.events, Event{
+		Time: time.Since(r.start),
+		Data: append([]byte{}, p...),
+	})
+
+	return len(p), nil
+}
+
+// resize sets the terminal size the output was recorded with, changes of a
+// known size after the start of the recording are kept as resize events
+func (r *Recording) resize(cols uint16, rows uint16) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.start.IsZero() {
+		r.cols, r.rows = cols, rows
+		return
+	}
+
+	r.resizeAt(time.Since(r.start), cols, rows)
+}
+
+// resizeAt changes the terminal size at the given point in time of the
+// recording, the caller has to hold the lock
+func (r *Recording) resizeAt(at time.Duration, cols uint16, rows uint16) {
+	if cols == r.cols && rows == r.rows {
+		return
+	}
+
+	if r.cols == 0 || r.rows == 0 {
+		r.cols, r.rows = cols, rows
+		return
+	}
+
+	if len(r.resizes) == 0 {
+		r.initialCols, r.initialRows = r.cols, r.rows
+	}
+
+	r.resizes = append(r.resizes, Resize{Time: at, Cols: cols, Rows: rows})
+	r.cols, r.rows = cols, rows
+}
+
+// Size returns the terminal size the output was recorded with, which is zero
+// in case the size is not known
+func (r *Recording) Size() (cols uint16, rows uint16) {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.cols, r.rows
+}
+
+// InitialSize returns the terminal size at the start of the recording, which
+// differs from the final size in case the terminal was resized
+func (r *Recording) InitialSize() (cols uint16, rows uint16) {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(r.resizes) > 0 {
+		return r.initialCols, r.initialRows
+	}
+
+	return r.cols, r.rows
+}
+
+// SizeAt returns the terminal size at the given point in time of the
+// recording, or the final size if the given time is zero
+func (r *Recording) SizeAt(at time.Duration) (cols uint16, rows uint16) {
+	if at <= 0 {
+		return r.Size()
+	}
+
+	cols, rows = r.InitialSize()
+	for _, resize := range r.Resizes() {
+		if resize.Time > at {
+			break
+		}
+
+		cols, rows = resize.Cols, resize.Rows
+	}
+
+	return cols, rows
+}
+
+// Resizes returns all changes of the terminal size during the recording
+func (r *Recording) Resizes() []Resize {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]Resize{}, r.resizes...)
+}
+
+// Events returns all events of the recording
+func (r *Recording) Events() []Event {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]Event{}, r.events...)
+}
+
+// Output returns the accumulated output up to the given point in time of the
+// recording, or the complete output if the given time is zero
+func (r *Recording) Output(at time.Duration) []byte {
+	var data []byte
+	for _, event := range r.Events() {
+		if at > 0 && event.Time > at {
+			break
+		}
+
+		data = append(data, event.Data...)
+	}
+
+	return completeSequences(data)
+}
+
+// Frames samples the recording with the given frame rate, events within the
+// same frame interval are combined into one frame. Pauses longer than the
+// maximum idle time are shortened to that time, unless it is zero.
+func (r *Recording) Frames(fps float64, maxIdle time.Duration) []Frame {
+	var events = r.Events()
+	if len(events) == 0 {
+		return nil
+	}
+
+	if fps <= 0 {
+		fps = 10
+	}
+
+	var interval = time.Duration(float64(time.Second) / fps)
+
+	// Compress idle times between the events
+	var times = make([]time.Duration, len(events))
+	var previous, offset time.Duration
+	for i, event := range events {
+		if gap := event.Time - previous; maxIdle > 0 && gap > maxIdle {
+			offset += gap - maxIdle
+		}
+
+		previous = event.Time
+		times[i] = event.Time - offset
+	}
+
+	// Combine all events of the same frame interval
+	var frames []Frame
+	var slots []time.Duration
+	var data []byte
+	for i, event := range events {
+		data = append(data, event.Data...)
+
+		slot := (times[i] / interval) * interval
+		if i+1 < len(events) && (times[i+1]/interval)*interval == slot {
+			continue
+		}
+
+		frames = append(frames, Frame{Data: completeSequences(data)})
+		slots = append(slots, slot)
+	}
+
+	for i := range frames {
+		switch {
+		case i+1 < len(frames):
+			frames[i].Delay = slots[i+1] - slots[i]
+
+		default:
+			frames[i].Delay = finalFrameHold
+		}
+	}
+
+	return frames
+}
+
+// completeSequences returns the data without a trailing incomplete escape
+// sequence, which can occur when a sequence is split across two reads
+func completeSequences(data []byte) []byte {
+	var idx = bytes.LastIndexByte(data, '\x1b')
+	if idx < 0 {
+		return append([]byte{}, data...)
+	}
+
+	var complete bool
+	var seq = data[idx+1:]
+	switch {
+	case len(seq) == 0:
+		complete = false
+
+	case seq[0] == '[': // CSI, ends with a byte in the range 0x40 to 0x7E
+		for _, b := range seq[1:] {
+			if b >= 0x40 && b <= 0x7E {
+				complete = true
+				break
+			}
+		}
+
+	case seq[0] == ']': // OSC, ends with BEL or the string terminator
+		complete = bytes.IndexByte(seq, '\a') >= 0 || bytes.Contains(seq, []byte("\x1b\\"))
+
+	default:
+		complete = true
+	}
+
+	if !complete {
+		return append([]byte{}, data[:idx]...)
+	}
+
+	return append([]byte{}, data...)
+}
@@ -0,0 +1,73 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Result is the outcome of a command that was run in a pseudo terminal
+type Result struct {
+	// Output is everything the command wrote to the pseudo terminal, which
+	// includes the styled standard error in case it is captured separately
+	Output []byte
+
+	// Stdout is the output of the pseudo terminal without the standard error,
+	// only set in case the standard error is captured separately
+	Stdout []byte
+
+	// Stderr is the standard error as-is, only set in case it is captured
+	// separately
+	Stderr []byte
+
+	// ExitCode is the exit code of the command, or -1 in case the command
+	// was terminated by a signal
+	ExitCode int
+
+	// Signal is the signal that terminated the command, if any
+	Signal syscall.Signal
+
+	// Duration is the time from the start of the command until it exited
+	Duration time.Duration
+
+	// Terminated is set in case the command was terminated on purpose, for
+	// example after a snapshot was taken or the context was done
+	Terminated bool
+}
+
+// Success returns whether the command exited with exit code zero
+func (r *Result) Success() bool {
+	return r.ExitCode == 0
+}
+
+// Status returns a short description of how the command exited, for
+// example "exit 1", or "killed by SIGKILL" in case of a signal
+func (r *Result) Status() string {
+	if r.Signal != 0 {
+		return fmt.Sprintf("killed by %s", unix.SignalName(r.Signal))
+	}
+
+	return fmt.Sprintf("exit %d", r.ExitCode)
+}
@@ -0,0 +1,276 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxRoot is the fixed path of the sandbox, so that paths shown in the
+// output, like the working directory, are the same on every run. Only one
+// sandbox can exist at a time, which is ensured with a lock file.
+var sandboxRoot = "/tmp/termshot-sandbox"
+
+// sandboxTime is the modification time of all files in a sandbox, so that
+// listings of the files do not depend on when the screenshot was made
+var sandboxTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// sandboxEnvironment are the environment variables with fixed values in a
+// sandbox, in addition to the ones of a clean environment
+var sandboxEnvironment = map[string]string{
+	"USER":     "termshot",
+	"LOGNAME":  "termshot",
+	"HOSTNAME": "termshot",
+	"TZ":       "UTC",
+	"LANG":     "C.UTF-8",
+	"LC_ALL":   "C.UTF-8",
+	"PAGER":    "cat",
+
+	// commits made in the sandbox do not depend on the git configuration
+	// of the machine, or on the current time
+	"GIT_CONFIG_NOSYSTEM": "1",
+	"GIT_AUTHOR_NAME":     "termshot",
+	"GIT_AUTHOR_EMAIL":    "termshot@example.com",
+	"GIT_AUTHOR_DATE":     sandboxTime.Format(time.RFC3339),
+	"GIT_COMMITTER_NAME":  "termshot",
+	"GIT_COMMITTER_EMAIL": "termshot@example.com",
+	"GIT_COMMITTER_DATE":  sandboxTime.Format(time.RFC3339),
+}
+
+// sandbox is a throwaway directory with the home directory of the command,
+// which is also its working directory, and a directory for temporary files
+type sandbox struct {
+	root string
+	home string
+	tmp  string
+	lock *os.File
+}
+
+// newSandbox creates a sandbox, the home directory contains a copy of the
+// fixtures directory, if any. It waits until other sandboxes are removed,
+// and deletes the leftovers of a sandbox that was not removed.
+func newSandbox(fixtures string) (*sandbox, error) {
+	lock, err := os.OpenFile(sandboxRoot+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+
+	// the lock file is shared by all users, independent of the umask of
+	// the one who created it, which is the only one allowed to change it
+	_ = lock.Chmod(0666)
+
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+
+	box := &sandbox{
+		root: sandboxRoot,
+		home: filepath.Join(sandboxRoot, "home"),
+		tmp:  filepath.Join(sandboxRoot, "tmp"),
+		lock: lock,
+	}
+
+	if err := box.setup(fixtures); err != nil {
+		_ = box.remove()
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+
+	return box, nil
+}
+
+// setup creates the directories of the sandbox, and sets fixed permissions
+// and modification times that do not depend on the umask or the current time
+func (box *sandbox) setup(fixtures string) error {
+	if err := box.clear(); err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(box.root, 0700); err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(box.tmp, 0700); err != nil {
+		return err
+	}
+
+	switch fixtures {
+	case "":
+		if err := os.Mkdir(box.home, 0755); err != nil {
+			return err
+		}
+
+	default:
+		if err := copyTree(fixtures, box.home); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Chmod(box.root, 0755); err != nil {
+		return err
+	}
+
+	var times = []unix.Timeval{unix.NsecToTimeval(sandboxTime.UnixNano()), unix.NsecToTimeval(sandboxTime.UnixNano())}
+	return filepath.WalkDir(box.root, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// the times of symbolic links are set without following them
+		return unix.Lutimes(path, times)
+	})
+}
+
+// remove deletes the sandbox including all files created by the command,
+// and releases the lock for the next sandbox
+func (box *sandbox) remove() error {
+	if err := box.clear(); err != nil {
+		_ = box.unlock()
+		return fmt.Errorf("failed to remove sandbox: %w", err)
+	}
+
+	return box.unlock()
+}
+
+// clear deletes the directory of the sandbox, all directories are made
+// writable first, since the fixtures or the command can leave read-only
+// directories behind
+func (box *sandbox) clear() error {
+	_ = filepath.WalkDir(box.root, func(path string, entry fs.DirEntry, err error) error {
+		// directories are visited before their entries are read, so that
+		// unreadable directories can be fixed before descending into them
+		if err == nil && entry.IsDir() {
+			_ = os.Chmod(path, 0700)
+		}
+
+		return nil
+	})
+
+	return os.RemoveAll(box.root)
+}
+
+// unlock releases the lock of the sandbox, closing the file is sufficient
+func (box *sandbox) unlock() error {
+	return box.lock.Close()
+}
+
+// dir returns the working directory in the sandbox, relative directories
+// are relative to the home directory
+func (box *sandbox) dir(dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return filepath.Join(box.home, dir)
+}
+
+// copyTree copies the directory with all files, directories, and symbolic
+// links, keeping their permissions, directory permissions are set at the end
+// so that read-only directories can be filled
+func copyTree(src string, dst string) error {
+	type dir struct {
+		path string
+		perm fs.FileMode
+	}
+
+	var dirs []dir
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			dirs = append(dirs, dir{path: target, perm: info.Mode().Perm()})
+			return os.Mkdir(target, 0700)
+
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+
+		case entry.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+
+		default:
+			return fmt.Errorf("unsupported file type of %s", path)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].perm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the content of a regular file
+func copyFile(src string, dst string, perm fs.FileMode) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, perm)
+}
@@ -0,0 +1,328 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonvenience/bunt"
+)
+
+// defaultWaitTimeout is the time a wait step waits for the text to appear,
+// unless a timeout is configured in the script
+const defaultWaitTimeout = 10 * time.Second
+
+// keys are the input sequences of named keys as sent by an xterm compatible
+// terminal, the names are case-insensitive
+var keys = map[string]string{
+	"enter":     "\r",
+	"return":    "\r",
+	"tab":       "\t",
+	"space":     " ",
+	"backspace": "\x7f",
+	"escape":    "\x1b",
+	"esc":       "\x1b",
+	"up":        "\x1b[A",
+	"down":      "\x1b[B",
+	"right":     "\x1b[C",
+	"left":      "\x1b[D",
+	"home":      "\x1b[H",
+	"end":       "\x1b[F",
+	"insert":    "\x1b[2~",
+	"delete":    "\x1b[3~",
+	"pageup":    "\x1b[5~",
+	"pagedown":  "\x1b[6~",
+	"f1":        "\x1bOP",
+	"f2":        "\x1bOQ",
+	"f3":        "\x1bOR",
+	"f4":        "\x1bOS",
+	"f5":        "\x1b[15~",
+	"f6":        "\x1b[17~",
+	"f7":        "\x1b[18~",
+	"f8":        "\x1b[19~",
+	"f9":        "\x1b[20~",
+	"f10":       "\x1b[21~",
+	"f11":       "\x1b[23~",
+	"f12":       "\x1b[24~",
+}
+
+// Script is a sequence of steps that provide the input of a command instead
+// of the standard input, so that interactive commands can run unattended
+//
+// Each line of a script contains one step, empty lines and lines starting
+// with # are ignored. Text can be a quoted string with escapes like "\t".
+//
+//	send <text>               send the text
+//	key <name>...             send named keys, e.g. Enter, Tab, Up, Ctrl-C, Alt-x, or F1
+//	wait <text>               wait until the text is shown on the screen (at most 10s)
+//	wait "<text>" <timeout>   wait with a different timeout, e.g. 30s
+//	sleep <duration>          pause for the given duration, e.g. 500ms
+//	snapshot                  take the output shown so far and terminate the command
+type Script struct {
+	steps []step
+}
+
+type stepKind int
+
+const (
+	stepSend stepKind = iota
+	stepWait
+	stepSleep
+	stepSnapshot
+)
+
+type step struct {
+	line     int
+	kind     stepKind
+	text     string
+	duration time.Duration
+}
+
+// ParseScript reads a script from the provided reader
+func ParseScript(r io.Reader) (*Script, error) {
+	var script Script
+	var scanner = bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		step, err := parseStep(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse script line %d: %w", number, err)
+		}
+
+		step.line = number
+		script.steps = append(script.steps, step)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	return &script, nil
+}
+
+// run executes the steps of the script, the input is written to the pseudo
+// terminal and the output is used to wait for text to be shown
+func (s *Script) run(pt io.Writer, out *output, exited <-chan struct{}, snapshot func()) error {
+	for _, step := range s.steps {
+		switch step.kind {
+		case stepSend:
+			if _, err := io.WriteString(pt, step.text); err != nil {
+				select {
+				case <-exited:
+					return nil
+
+				default:
+					return fmt.Errorf("failed to send input of script line %d: %w", step.line, err)
+				}
+			}
+
+		case stepWait:
+			if err := waitFor(out, step.text, step.duration, exited); err != nil {
+				return fmt.Errorf("failed to run script line %d: %w", step.line, err)
+			}
+
+		case stepSleep:
+			select {
+			case <-exited:
+				return nil
+
+			case <-time.After(step.duration):
+			}
+
+		case stepSnapshot:
+			snapshot()
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// waitFor waits until the text is shown on the screen
+func waitFor(out *output, text string, timeout time.Duration, exited <-chan struct{}) error {
+	var deadline = time.After(timeout)
+	var ticker = time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !out.shows(text) {
+		select {
+		case <-exited:
+			if out.shows(text) {
+				return nil
+			}
+
+			return fmt.Errorf("command exited before %q was shown", text)
+
+		case <-deadline:
+			return fmt.Errorf("timed out after %v waiting for %q to be shown", timeout, text)
+
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+func parseStep(line string) (step, error) {
+	name, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch name {
+	case "send":
+		text, err := argument(rest)
+		if err != nil {
+			return step{}, err
+		}
+
+		return step{kind: stepSend, text: text}, nil
+
+	case "key", "keys":
+		var sb strings.Builder
+		for _, name := range strings.Fields(rest) {
+			sequence, err := key(name)
+			if err != nil {
+				return step{}, err
+			}
+
+			sb.WriteString(sequence)
+		}
+
+		if sb.Len() == 0 {
+			return step{}, fmt.Errorf("no key specified")
+		}
+
+		return step{kind: stepSend, text: sb.String()}, nil
+
+	case "wait":
+		var text, timeout = rest, ""
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return step{}, fmt.Errorf("invalid quoted text %s", rest)
+			}
+
+			text, timeout = quoted, strings.TrimSpace(rest[len(quoted):])
+		}
+
+		text, err := argument(text)
+		if err != nil {
+			return step{}, err
+		}
+
+		if text == "" {
+			return step{}, fmt.Errorf("no text to wait for specified")
+		}
+
+		var duration = defaultWaitTimeout
+		if timeout != "" {
+			if duration, err = time.ParseDuration(timeout); err != nil {
+				return step{}, fmt.Errorf("invalid timeout: %w", err)
+			}
+		}
+
+		return step{kind: stepWait, text: text, duration: duration}, nil
+
+	case "sleep":
+		duration, err := time.ParseDuration(rest)
+		if err != nil {
+			return step{}, fmt.Errorf("invalid duration: %w", err)
+		}
+
+		return step{kind: stepSleep, duration: duration}, nil
+
+	case "snapshot":
+		if rest != "" {
+			return step{}, fmt.Errorf("unexpected argument %q", rest)
+		}
+
+		return step{kind: stepSnapshot}, nil
+
+	default:
+		return step{}, fmt.Errorf("unknown step %q, supported steps are send, key, wait, sleep, and snapshot", name)
+	}
+}
+
+// argument returns the text as-is, or unquoted in case it is a quoted string
+func argument(text string) (string, error) {
+	if !strings.HasPrefix(text, `"`) {
+		return text, nil
+	}
+
+	unquoted, err := strconv.Unquote(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted text %s", text)
+	}
+
+	return unquoted, nil
+}
+
+// plain returns the text of the content without any text settings
+func plain(content bunt.String) string {
+	var sb strings.Builder
+	for _, cr := range content {
+		sb.WriteRune(cr.Symbol)
+	}
+
+	return sb.String()
+}
+
+// key returns the input sequence of a named key, including combinations with
+// the control or alt modifier
+func key(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, "ctrl-") && len(lower) == len("ctrl-")+1:
+		c := lower[len(lower)-1]
+		if c < '@' || c > '_' && (c < 'a' || c > 'z') {
+			return "", fmt.Errorf("unsupported key %q", name)
+		}
+
+		return string(rune(c & 0x1F)), nil
+
+	case strings.HasPrefix(lower, "alt-") && len(name) > len("alt-"):
+		sequence, err := key(name[len("alt-"):])
+		if err != nil {
+			return "", err
+		}
+
+		return "\x1b" + sequence, nil
+	}
+
+	if sequence, ok := keys[lower]; ok {
+		return sequence, nil
+	}
+
+	// single characters are sent as they are
+	if len([]rune(name)) == 1 {
+		return name, nil
+	}
+
+	return "", fmt.Errorf("unsupported key %q", name)
+}
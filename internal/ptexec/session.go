@@ -0,0 +1,171 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sessionMarker is written by the shell before each command of a session,
+// it is an operating system command ignored by terminals, which is replaced
+// with the echo of the command in the output
+const sessionMarker = "\x1b]termshot;command;"
+
+// Session is a sequence of commands run one after another in the same shell,
+// so that the shell state like the working directory or exported variables
+// is shared like in an interactive terminal session
+type Session struct {
+	commands []string
+}
+
+// ParseSession reads the commands of a session from the provided reader
+//
+// Each line contains one command, a line ending with a backslash continues
+// on the next line. Empty lines and lines starting with # are ignored.
+func ParseSession(r io.Reader) (*Session, error) {
+	var session Session
+	var command strings.Builder
+	var scanner = bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); command.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			continue
+		}
+
+		if strings.HasSuffix(line, `\`) {
+			command.WriteString(strings.TrimSuffix(line, `\`))
+			continue
+		}
+
+		command.WriteString(line)
+		session.commands = append(session.commands, strings.TrimSpace(command.String()))
+		command.Reset()
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	if command.Len() > 0 {
+		session.commands = append(session.commands, strings.TrimSpace(command.String()))
+	}
+
+	if len(session.commands) == 0 {
+		return nil, fmt.Errorf("no commands in session")
+	}
+
+	return &session, nil
+}
+
+// sessionScript returns the script that runs the commands of the session,
+// each one is preceded by the marker with its index
+func (c *PseudoTerminal) sessionScript(syntax shellSyntax) string {
+	var lines []string
+	if c.shellConfig != "" {
+		lines = append(lines, syntax.source(syntax.word(c.config())))
+	}
+
+	for i, command := range c.session.commands {
+		lines = append(lines,
+			fmt.Sprintf(`printf '\033]termshot;command;%d\007'`, i),
+			syntax.eval(syntax.quote(command)),
+		)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// sessionWriter replaces the markers written before each command of a
+// session with the echo of the command
+type sessionWriter struct {
+	out      io.Writer
+	commands []string
+	echo     func(command string) string
+	pending  []byte
+}
+
+func (w *sessionWriter) Write(p []byte) (int, error) {
+	var data = append(w.pending, p...)
+	w.pending = nil
+
+	var buf bytes.Buffer
+	for len(data) > 0 {
+		start := bytes.Index(data, []byte(sessionMarker))
+		if start < 0 {
+			// keep the beginning of a marker, which continues in the next write
+			keep := partialSuffix(data, sessionMarker)
+			buf.Write(data[:len(data)-keep])
+			w.pending = append(w.pending, data[len(data)-keep:]...)
+			break
+		}
+
+		end := bytes.IndexByte(data[start:], '\a')
+		if end < 0 {
+			buf.Write(data[:start])
+			w.pending = append(w.pending, data[start:]...)
+			break
+		}
+
+		buf.Write(data[:start])
+		index, err := strconv.Atoi(string(data[start+len(sessionMarker) : start+end]))
+		if err == nil && index >= 0 && index < len(w.commands) && w.echo != nil {
+			buf.WriteString(w.echo(w.commands[index]))
+		}
+
+		data = data[start+end+1:]
+	}
+
+	if buf.Len() > 0 {
+		if _, err := w.out.Write(buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// flush writes what was kept back as the possible beginning of a marker
+func (w *sessionWriter) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	_, err := w.out.Write(w.pending)
+	w.pending = nil
+	return err
+}
+
+// partialSuffix returns the length of the longest end of the data, which is
+// the beginning of the marker
+func partialSuffix(data []byte, marker string) int {
+	for n := min(len(data), len(marker)-1); n > 0; n-- {
+		if bytes.HasSuffix(data, []byte(marker[:n])) {
+			return n
+		}
+	}
+
+	return 0
+}
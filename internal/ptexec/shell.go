@@ -0,0 +1,184 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// shellSyntax describes how a command is written for a specific shell, so
+// that arguments and the shell configuration file are quoted correctly
+type shellSyntax struct {
+	// safe matches words that do not need to be quoted
+	safe *regexp.Regexp
+
+	// quote returns the word as a quoted string
+	quote func(string) string
+
+	// source returns the statement that reads the configuration
+	source func(config string) string
+
+	// and joins two statements, the second only runs if the first succeeded
+	and string
+
+	// eval returns the command that runs the quoted line of a session, which
+	// must not exit the shell in case of a syntax error, it is not set for
+	// shells that do not support sessions
+	eval func(quoted string) string
+}
+
+var (
+	posixShell = shellSyntax{
+		safe: regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`),
+
+		// single quotes preserve everything, a single quote itself is
+		// written by ending the quoted string, and adding an escaped one
+		quote: func(word string) string {
+			return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+		},
+
+		// the dot command is the portable form of source
+		source: func(config string) string {
+			return ". " + config
+		},
+
+		and: " && ",
+
+		// command prevents that errors of the special built-in eval exit
+		// the shell
+		eval: func(quoted string) string {
+			return "command eval " + quoted
+		},
+	}
+
+	fishShell = shellSyntax{
+		safe: regexp.MustCompile(`^[A-Za-z0-9_+=:,./-]+$`),
+
+		// single quotes only support escaping single quotes and backslashes
+		quote: func(word string) string {
+			return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(word) + "'"
+		},
+
+		source: func(config string) string {
+			return "source " + config
+		},
+
+		and: "; and ",
+
+		eval: func(quoted string) string {
+			return "eval " + quoted
+		},
+	}
+
+	nuShell = shellSyntax{
+		safe: regexp.MustCompile(`^[A-Za-z0-9_./-]+$`),
+
+		// single quoted strings do not support escapes, so words with single
+		// quotes use double quoted strings with escapes instead
+		quote: func(word string) string {
+			if !strings.Contains(word, "'") {
+				return "'" + word + "'"
+			}
+
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+		},
+
+		// a failing source is a parse error, so there is no need for a
+		// conditional
+		source: func(config string) string {
+			return "source " + config
+		},
+
+		and: "; ",
+	}
+)
+
+// syntaxOf returns the syntax of the shell based on its executable name,
+// all shells that are not known otherwise are considered POSIX compatible
+func syntaxOf(shell string) shellSyntax {
+	switch filepath.Base(shell) {
+	case "fish":
+		return fishShell
+
+	case "nu", "nushell":
+		return nuShell
+
+	default:
+		return posixShell
+	}
+}
+
+// word returns the word as-is in case it does not contain any characters
+// with a special meaning for the shell, otherwise it is quoted
+func (s shellSyntax) word(word string) string {
+	if s.safe.MatchString(word) {
+		return word
+	}
+
+	return s.quote(word)
+}
+
+// commandLine returns the command with its arguments as one line, a command
+// name containing spaces is a script and is used as-is, while the arguments
+// are always quoted when needed
+func (s shellSyntax) commandLine(name string, args []string) string {
+	var words = []string{name}
+	if !strings.Contains(name, " ") {
+		words[0] = s.word(name)
+	}
+
+	for _, arg := range args {
+		words = append(words, s.word(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// CommandLine returns the command with its arguments as one line for a POSIX
+// shell, quoted the same way as commands that run with a shell
+func CommandLine(name string, args ...string) string {
+	return posixShell.commandLine(name, args)
+}
+
+// shellCommand returns the command line to run the command with the shell,
+// with the shell configuration being read first in case it is set
+func (c *PseudoTerminal) shellCommand() string {
+	syntax := syntaxOf(c.shell)
+
+	command := syntax.commandLine(c.name, c.args)
+	if c.shellConfig == "" {
+		return command
+	}
+
+	return syntax.source(syntax.word(c.config())) + syntax.and + command
+}
+
+// config returns the shell configuration file, a file without a directory is
+// taken from the current directory, instead of being looked up in the PATH
+func (c *PseudoTerminal) config() string {
+	if !strings.Contains(c.shellConfig, "/") {
+		return "./" + c.shellConfig
+	}
+
+	return c.shellConfig
+}
@@ -0,0 +1,95 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ptexec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// StderrStyle defines how the standard error of a command is shown in the
+// output, in case it is captured separately from the pseudo terminal
+type StderrStyle struct {
+	// Color is the escape sequence used for the text, e.g. "\x1b[31m"
+	Color string
+
+	// Gutter is shown at the start of each line, e.g. "▎ "
+	Gutter string
+}
+
+// stderrWriter merges the standard error into the output using the style,
+// and keeps the standard error as-is
+type stderrWriter struct {
+	out     io.Writer
+	style   StderrStyle
+	raw     bytes.Buffer
+	midLine bool
+}
+
+func (w *stderrWriter) Write(p []byte) (int, error) {
+	w.raw.Write(p)
+
+	var buf bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if !w.midLine {
+			buf.WriteString(w.style.Gutter)
+			w.midLine = true
+		}
+
+		line, more, found := bytes.Cut(rest, []byte("\n"))
+		if len(line) > 0 {
+			buf.WriteString(w.style.Color)
+			buf.Write(line)
+			if w.style.Color != "" {
+				buf.WriteString("\x1b[0m")
+			}
+		}
+
+		// A pipe does not translate newlines like the pseudo terminal does,
+		// so a carriage return is added to start the next line at column 0
+		if found {
+			buf.WriteString("\r\n")
+			w.midLine = false
+		}
+
+		rest = more
+	}
+
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// lockedWriter serializes writes, so that the pseudo terminal output and the
+// standard error can be written to the same writer
+type lockedWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,115 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// base16Mapping is the standard mapping of base16 colors onto the ANSI
+// colors 0-15 of a terminal, the bright colors use the same colors except for
+// bright black and bright white
+var base16Mapping = [16]string{
+	"base00", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base05",
+	"base03", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base07",
+}
+
+// base24Bright are the bright colors of base24 schemes, which replace the
+// bright colors 9-14 of the base16 mapping
+var base24Bright = map[int]string{
+	9:  "base12",
+	10: "base14",
+	11: "base13",
+	12: "base16",
+	13: "base17",
+	14: "base15",
+}
+
+// base16Theme derives a theme from a base16 or base24 scheme, which is
+// either in the current format with a palette, or in the legacy format with
+// the colors at the top level
+func base16Theme(data []byte) (Theme, error) {
+	var scheme struct {
+		System  string            `yaml:"system"`
+		Name    string            `yaml:"name"`
+		Scheme  string            `yaml:"scheme"`
+		Palette map[string]string `yaml:"palette"`
+	}
+
+	if err := yaml.Unmarshal(data, &scheme); err != nil {
+		return Theme{}, err
+	}
+
+	var colors = scheme.Palette
+	if colors == nil {
+		var legacy map[string]any
+		if err := yaml.Unmarshal(data, &legacy); err != nil {
+			return Theme{}, err
+		}
+
+		colors = map[string]string{}
+		for name, value := range legacy {
+			if text, ok := value.(string); ok && strings.HasPrefix(name, "base") {
+				colors[name] = text
+			}
+		}
+	}
+
+	// names of colors are not case sensitive, i.e. base0B and base0b
+	var lookup = map[string]string{}
+	for name, value := range colors {
+		lookup[strings.ToLower(name)] = value
+	}
+
+	var base24 = scheme.System == "base24" || lookup["base10"] != ""
+	var color = func(name string) (string, error) {
+		value, ok := lookup[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("missing color %s", name)
+		}
+
+		return normalizeColor(value)
+	}
+
+	var required = []string{"base00", "base01", "base02", "base03", "base04", "base05", "base06", "base07", "base08", "base09", "base0A", "base0B", "base0C", "base0D", "base0E", "base0F"}
+	if base24 {
+		required = append(required, "base10", "base11", "base12", "base13", "base14", "base15", "base16", "base17")
+	}
+
+	for _, name := range required {
+		if _, err := color(name); err != nil {
+			return Theme{}, err
+		}
+	}
+
+	var p = palette{name: scheme.Name}
+	if p.name == "" {
+		p.name = scheme.Scheme
+	}
+
+	p.background, _ = color("base00")
+	p.foreground, _ = color("base05")
+	for i, name := range base16Mapping {
+		p.ansi[i], _ = color(name)
+	}
+
+	// the darkest background is used for the shadow, like the preset themes
+	var shadow, _ = color("base00")
+	if base24 {
+		for i, name := range base24Bright {
+			p.ansi[i], _ = color(name)
+		}
+
+		shadow, _ = color("base11")
+	}
+
+	theme, err := p.theme()
+	if err != nil {
+		return Theme{}, err
+	}
+
+	theme.WindowBorder, _ = color("base02")
+	theme.Shadow = shadow + "66"
+	return theme, nil
+}
@@ -0,0 +1,593 @@
+package theme
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported theme file formats
+const (
+	formatTermshot        = "termshot"
+	formatITerm           = "iTerm2"
+	formatWindowsTerminal = "Windows Terminal"
+	formatAlacrittyTOML   = "Alacritty TOML"
+	formatAlacrittyYAML   = "Alacritty YAML"
+	formatKitty           = "kitty"
+	formatGhostty         = "Ghostty"
+	formatXresources      = "Xresources"
+	formatBase16          = "base16"
+)
+
+// ansiNames are the names of the ANSI colors 0-7, which are used by most
+// formats, the bright colors 8-15 use the same names
+var ansiNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// content patterns to detect the format of files without a known extension
+var (
+	base16Pattern     = regexp.MustCompile(`(?m)^\s*base0[0-9A-Fa-f]\s*:`)
+	ghosttyPattern    = regexp.MustCompile(`(?m)^\s*(palette|background|foreground)\s*=`)
+	tomlPattern       = regexp.MustCompile(`(?m)^\s*\[colors[.\]]`)
+	yamlPattern       = regexp.MustCompile(`(?m)^colors:\s*$`)
+	kittyPattern      = regexp.MustCompile(`(?m)^\s*(color\d+|background|foreground)[ \t]+\S`)
+	xresourcesPattern = regexp.MustCompile(`(?m)^\s*[\w.*-]*(color\d+|background|foreground)\s*:`)
+)
+
+// palette are the colors of a color scheme of a terminal, colors that are
+// not defined are empty
+type palette struct {
+	name       string
+	background string
+	foreground string
+	ansi       [16]string
+}
+
+// parseTheme parses the theme file in any of the supported formats, the
+// chain are the theme files that extend it
+func parseTheme(path string, data []byte, chain []string) (Theme, error) {
+	var p palette
+	var err error
+	switch detectFormat(path, data) {
+	case formatTermshot:
+		theme, err := jsonTheme(path, data, chain)
+		if err == nil && theme.Name == "" {
+			theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		return theme, err
+
+	case formatITerm:
+		p, err = iTermPalette(data)
+
+	case formatWindowsTerminal:
+		p, err = windowsTerminalPalette(data)
+
+	case formatAlacrittyTOML:
+		var doc map[string]any
+		if doc, err = parseTOML(data); err == nil {
+			p, err = alacrittyPalette(doc)
+		}
+
+	case formatAlacrittyYAML:
+		var doc map[string]any
+		if err = yaml.Unmarshal(data, &doc); err == nil {
+			p, err = alacrittyPalette(doc)
+		}
+
+	case formatKitty:
+		p, err = kittyPalette(data)
+
+	case formatGhostty:
+		p, err = ghosttyPalette(data)
+
+	case formatXresources:
+		p, err = xresourcesPalette(data)
+
+	case formatBase16:
+		return base16Theme(data)
+
+	default:
+		return Theme{}, fmt.Errorf("unknown format, supported formats are termshot JSON, iTerm2, Windows Terminal, Alacritty, kitty, Ghostty, Xresources, and base16")
+	}
+
+	if err != nil {
+		return Theme{}, err
+	}
+
+	if p.name == "" {
+		p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return p.theme()
+}
+
+// detectFormat returns the format of the theme file based on the extension,
+// or based on the content for files without a known extension
+func detectFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".itermcolors":
+		return formatITerm
+
+	case ".json":
+		return jsonFormat(data)
+
+	case ".toml":
+		return formatAlacrittyTOML
+
+	case ".yml", ".yaml":
+		if base16Pattern.Match(data) {
+			return formatBase16
+		}
+
+		return formatAlacrittyYAML
+
+	case ".xresources", ".xdefaults":
+		return formatXresources
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return jsonFormat(data)
+
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.Contains(trimmed, []byte("<plist")):
+		return formatITerm
+
+	case base16Pattern.Match(data):
+		return formatBase16
+
+	case ghosttyPattern.Match(data):
+		return formatGhostty
+
+	case tomlPattern.Match(data):
+		return formatAlacrittyTOML
+
+	case yamlPattern.Match(data):
+		return formatAlacrittyYAML
+
+	case kittyPattern.Match(data):
+		return formatKitty
+
+	case xresourcesPattern.Match(data):
+		return formatXresources
+
+	default:
+		return ""
+	}
+}
+
+// jsonFormat distinguishes termshot themes from Windows Terminal schemes,
+// which use camel case names and purple instead of magenta
+func jsonFormat(data []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return formatTermshot
+	}
+
+	for _, name := range []string{"schemes", "purple", "brightBlack"} {
+		if _, ok := fields[name]; ok {
+			return formatWindowsTerminal
+		}
+	}
+
+	return formatTermshot
+}
+
+// theme maps the palette onto a theme, colors that are not defined are
+// taken from the default theme, and the window decorations use the colors
+// of the palette like the preset themes do
+func (p palette) theme() (Theme, error) {
+	var defined bool
+	var colors = []*string{&p.background, &p.foreground}
+	for i := range p.ansi {
+		colors = append(colors, &p.ansi[i])
+	}
+
+	for _, c := range colors {
+		if *c == "" {
+			continue
+		}
+
+		normalized, err := normalizeColor(*c)
+		if err != nil {
+			return Theme{}, err
+		}
+
+		*c, defined = normalized, true
+	}
+
+	if !defined {
+		return Theme{}, fmt.Errorf("no colors found")
+	}
+
+	var fallback = GetTheme("default")
+	var or = func(value string, fallback string) string {
+		if value == "" {
+			return fallback
+		}
+
+		return value
+	}
+
+	theme := Theme{
+		Name:          p.name,
+		Background:    or(p.background, fallback.Background),
+		Foreground:    or(p.foreground, fallback.Foreground),
+		Shadow:        fallback.Shadow,
+		Black:         or(p.ansi[0], fallback.Black),
+		Red:           or(p.ansi[1], fallback.Red),
+		Green:         or(p.ansi[2], fallback.Green),
+		Yellow:        or(p.ansi[3], fallback.Yellow),
+		Blue:          or(p.ansi[4], fallback.Blue),
+		Magenta:       or(p.ansi[5], fallback.Magenta),
+		Cyan:          or(p.ansi[6], fallback.Cyan),
+		White:         or(p.ansi[7], fallback.White),
+		BrightBlack:   or(p.ansi[8], fallback.BrightBlack),
+		BrightRed:     or(p.ansi[9], fallback.BrightRed),
+		BrightGreen:   or(p.ansi[10], fallback.BrightGreen),
+		BrightYellow:  or(p.ansi[11], fallback.BrightYellow),
+		BrightBlue:    or(p.ansi[12], fallback.BrightBlue),
+		BrightMagenta: or(p.ansi[13], fallback.BrightMagenta),
+		BrightCyan:    or(p.ansi[14], fallback.BrightCyan),
+		BrightWhite:   or(p.ansi[15], fallback.BrightWhite),
+	}
+
+	theme.WindowRed = theme.Red
+	theme.WindowYellow = theme.Yellow
+	theme.WindowGreen = theme.Green
+	theme.WindowBorder = theme.BrightBlack
+
+	return theme, nil
+}
+
+// normalizeColor returns the color as #rrggbb, supported are hex colors with
+// a #, 0x, or no prefix, short hex colors, and X11 rgb:r/g/b colors
+func normalizeColor(value string) (string, error) {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+	if rgb, ok := strings.CutPrefix(value, "rgb:"); ok {
+		components := strings.Split(rgb, "/")
+		if len(components) != 3 {
+			return "", fmt.Errorf("invalid color %q", value)
+		}
+
+		var result = "#"
+		for _, component := range components {
+			n, err := strconv.ParseUint(component, 16, 16)
+			if err != nil || len(component) == 0 || len(component) > 4 {
+				return "", fmt.Errorf("invalid color %q", value)
+			}
+
+			// components have one to four hex digits, scaled to eight bits
+			scale := float64(uint64(1)<<(4*len(component)) - 1)
+			result += fmt.Sprintf("%02x", int(math.Round(float64(n)/scale*255)))
+		}
+
+		return result, nil
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(value, "#"), "0x"), "0X")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil || len(hex) != 6 {
+		return "", fmt.Errorf("invalid color %q", value)
+	}
+
+	return "#" + strings.ToLower(hex), nil
+}
+
+// iTermPalette reads the colors of an iTerm2 color preset, which is a
+// property list with a dictionary of color components for each color
+func iTermPalette(data []byte) (palette, error) {
+	var p palette
+	var decoder = xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return palette{}, fmt.Errorf("failed to parse property list: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "dict" {
+			value, err := plistValue(decoder, start)
+			if err != nil {
+				return palette{}, err
+			}
+
+			colors, _ := value.(map[string]any)
+			for name, value := range colors {
+				components, ok := value.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				var rgb [3]float64
+				for i, component := range []string{"Red Component", "Green Component", "Blue Component"} {
+					rgb[i], _ = components[component].(float64)
+				}
+
+				hex := fmt.Sprintf("#%02x%02x%02x", int(math.Round(rgb[0]*255)), int(math.Round(rgb[1]*255)), int(math.Round(rgb[2]*255)))
+
+				var idx int
+				switch {
+				case name == "Background Color":
+					p.background = hex
+
+				case name == "Foreground Color":
+					p.foreground = hex
+
+				default:
+					if _, err := fmt.Sscanf(name, "Ansi %d Color", &idx); err == nil && idx >= 0 && idx < 16 {
+						p.ansi[idx] = hex
+					}
+				}
+			}
+
+			return p, nil
+		}
+	}
+}
+
+// plistValue decodes the value of a property list element, dictionaries are
+// maps, and numbers are floats
+func plistValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		var result = map[string]any{}
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse property list: %w", err)
+			}
+
+			switch typed := token.(type) {
+			case xml.EndElement:
+				return result, nil
+
+			case xml.StartElement:
+				if typed.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &typed); err != nil {
+						return nil, fmt.Errorf("failed to parse property list: %w", err)
+					}
+
+					continue
+				}
+
+				value, err := plistValue(decoder, typed)
+				if err != nil {
+					return nil, err
+				}
+
+				result[key] = value
+			}
+		}
+
+	case "real", "integer":
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse property list: %w", err)
+		}
+
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+
+	default:
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse property list: %w", err)
+		}
+
+		return text, nil
+	}
+}
+
+// windowsTerminalPalette reads a color scheme of Windows Terminal, which is
+// either a single scheme, or the settings with a list of schemes of which
+// the first one is used
+func windowsTerminalPalette(data []byte) (palette, error) {
+	type scheme map[string]string
+
+	var settings struct {
+		Schemes []scheme `json:"schemes"`
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return palette{}, err
+	}
+
+	var selected scheme
+	switch {
+	case settings.Schemes != nil && len(settings.Schemes) == 0:
+		return palette{}, fmt.Errorf("no color schemes found")
+
+	case settings.Schemes != nil:
+		selected = settings.Schemes[0]
+
+	default:
+		// a single scheme also contains fields other than strings, which are
+		// not colors
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return palette{}, err
+		}
+
+		selected = scheme{}
+		for name, value := range fields {
+			if text, ok := value.(string); ok {
+				selected[name] = text
+			}
+		}
+	}
+
+	var p = palette{
+		name:       selected["name"],
+		background: selected["background"],
+		foreground: selected["foreground"],
+	}
+
+	for i, name := range ansiNames {
+		if name == "magenta" {
+			name = "purple"
+		}
+
+		p.ansi[i] = selected[name]
+		p.ansi[i+8] = selected["bright"+strings.ToUpper(name[:1])+name[1:]]
+	}
+
+	return p, nil
+}
+
+// alacrittyPalette reads the colors of an Alacritty configuration, which is
+// the same for the TOML and the YAML format
+func alacrittyPalette(doc map[string]any) (palette, error) {
+	var table = func(m map[string]any, key string) map[string]any {
+		t, _ := m[key].(map[string]any)
+		return t
+	}
+
+	var text = func(value any) string {
+		switch typed := value.(type) {
+		case string:
+			return typed
+
+		case int:
+			// unquoted 0x prefixed colors are numbers in YAML
+			return fmt.Sprintf("#%06x", typed)
+
+		default:
+			return ""
+		}
+	}
+
+	colors := table(doc, "colors")
+	if colors == nil {
+		return palette{}, fmt.Errorf("no colors section found")
+	}
+
+	var p = palette{
+		background: text(table(colors, "primary")["background"]),
+		foreground: text(table(colors, "primary")["foreground"]),
+	}
+
+	for i, name := range ansiNames {
+		p.ansi[i] = text(table(colors, "normal")[name])
+		p.ansi[i+8] = text(table(colors, "bright")[name])
+	}
+
+	return p, nil
+}
+
+// kittyPalette reads the colors of a kitty configuration or theme, which has
+// one setting per line with the name and the value separated by spaces
+func kittyPalette(data []byte) (palette, error) {
+	var p palette
+	return p, eachLine(data, "#", func(line string) error {
+		name, value, _ := strings.Cut(strings.Join(strings.Fields(line), " "), " ")
+		p.set(name, value)
+		return nil
+	})
+}
+
+// ghosttyPalette reads the colors of a Ghostty configuration or theme, the
+// ANSI colors are set with palette = N=COLOR
+func ghosttyPalette(data []byte) (palette, error) {
+	var p palette
+	return p, eachLine(data, "#", func(line string) error {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "palette" {
+			idx, color, ok := strings.Cut(value, "=")
+			if !ok {
+				return fmt.Errorf("invalid palette entry %q", value)
+			}
+
+			name, value = "color"+strings.TrimSpace(idx), color
+		}
+
+		p.set(name, value)
+		return nil
+	})
+}
+
+// xresourcesPalette reads the colors of X resources, the name of a color
+// resource can have any prefix like *, *., or URxvt*, and values can use
+// constants defined with #define
+func xresourcesPalette(data []byte) (palette, error) {
+	var p palette
+	var defines = map[string]string{}
+	return p, eachLine(data, "!", func(line string) error {
+		if directive, ok := strings.CutPrefix(line, "#"); ok {
+			fields := strings.Fields(directive)
+			if len(fields) == 3 && fields[0] == "define" {
+				defines[fields[1]] = fields[2]
+			}
+
+			return nil
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+
+		name = strings.TrimSpace(name)
+		name = name[strings.LastIndexAny(name, ".*")+1:]
+		value = strings.TrimSpace(value)
+		if defined, ok := defines[value]; ok {
+			value = defined
+		}
+
+		p.set(name, value)
+		return nil
+	})
+}
+
+// set sets the color with the given name, which is background, foreground,
+// or colorN, other names are ignored
+func (p *palette) set(name string, value string) {
+	switch name {
+	case "background":
+		p.background = value
+
+	case "foreground":
+		p.foreground = value
+
+	default:
+		number, ok := strings.CutPrefix(name, "color")
+		if idx, err := strconv.Atoi(number); ok && err == nil && idx >= 0 && idx < len(p.ansi) {
+			p.ansi[idx] = value
+		}
+	}
+}
+
+// eachLine calls the function for each line that is neither empty nor a
+// comment starting with the given prefix
+func eachLine(data []byte, comment string, fn func(line string) error) error {
+	var scanner = bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, comment) {
+			continue
+		}
+
+		if err := fn(line); err != nil {
+			return fmt.Errorf("line %d: %w", number, err)
+		}
+	}
+
+	return scanner.Err()
+}
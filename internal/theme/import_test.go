@@ -0,0 +1,267 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package theme_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/theme"
+)
+
+var _ = Describe("Importing color schemes", func() {
+	Context("loading theme files of other terminals", func() {
+		It("should load the termshot JSON format as-is", func() {
+			custom := GetTheme("nord")
+			custom.Name, custom.Background = "Custom", "#101010"
+			custom.Palette = map[int]string{208: "#ff8700"}
+
+			data, err := json.Marshal(custom)
+			Expect(err).ToNot(HaveOccurred())
+
+			theme, err := load("custom.json", string(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme).To(Equal(custom))
+		})
+
+		It("should import iTerm2 color presets", func() {
+			theme, err := load("Solarized.itermcolors", `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Ansi 1 Color</key>
+	<dict>
+		<key>Alpha Component</key>
+		<real>1</real>
+		<key>Blue Component</key>
+		<real>0.18431372940540314</real>
+		<key>Color Space</key>
+		<string>sRGB</string>
+		<key>Green Component</key>
+		<real>0.19607843458652496</real>
+		<key>Red Component</key>
+		<real>0.86274510622024536</real>
+	</dict>
+	<key>Background Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0.21176470816135406</real>
+		<key>Green Component</key>
+		<real>0.16862745583057404</real>
+		<key>Red Component</key>
+		<integer>0</integer>
+	</dict>
+</dict>
+</plist>`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("Solarized"))
+			Expect(theme.Red).To(Equal("#dc322f"))
+			Expect(theme.WindowRed).To(Equal("#dc322f"))
+			Expect(theme.Background).To(Equal("#002b36"))
+		})
+
+		It("should import Windows Terminal schemes", func() {
+			theme, err := load("settings.json", `{
+  "defaultProfile": "{61c54bbd-c2c6-5271-96e7-009a87ff44bf}",
+  "schemes": [
+    {"name": "Campbell", "background": "#0C0C0C", "foreground": "#CCCCCC", "purple": "#881798", "brightPurple": "#B4009E", "brightBlack": "#767676"}
+  ]
+}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("Campbell"))
+			Expect(theme.Background).To(Equal("#0c0c0c"))
+			Expect(theme.Magenta).To(Equal("#881798"))
+			Expect(theme.BrightMagenta).To(Equal("#b4009e"))
+			Expect(theme.WindowBorder).To(Equal("#767676"))
+
+			theme, err = load("campbell.json", `{"name": "Campbell", "cursorColor": "#FFFFFF", "purple": "#881798"}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Magenta).To(Equal("#881798"))
+		})
+
+		It("should import Alacritty TOML and YAML configurations", func() {
+			theme, err := load("alacritty.toml", `
+# Colors (Tomorrow Night)
+[colors]
+bright = { red = "#ff3334", blue = "#81a2be" }
+
+[colors.primary]
+background = "#1d1f21" # comment
+foreground = '#c5c8c6'
+
+[colors.normal]
+red = "#cc6666"
+green = "0xb5bd68"
+
+[[hints.enabled]]
+regex = "[^ ]+#"
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("alacritty"))
+			Expect(theme.Background).To(Equal("#1d1f21"))
+			Expect(theme.Foreground).To(Equal("#c5c8c6"))
+			Expect(theme.Green).To(Equal("#b5bd68"))
+			Expect(theme.BrightRed).To(Equal("#ff3334"))
+			Expect(theme.BrightBlue).To(Equal("#81a2be"))
+
+			theme, err = load("alacritty.yml", `
+colors:
+  primary:
+    background: '0x1d1f21'
+    foreground: 0xc5c8c6
+  normal:
+    red: '#cc6666'
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Background).To(Equal("#1d1f21"))
+			Expect(theme.Foreground).To(Equal("#c5c8c6"))
+			Expect(theme.Red).To(Equal("#cc6666"))
+		})
+
+		It("should import kitty themes", func() {
+			theme, err := load("Tomorrow.conf", `
+# vim:ft=kitty
+background  #1d1f21
+foreground  #c5c8c6
+color1      #cc6666
+color9      #ff3334
+color100    #123456
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Background).To(Equal("#1d1f21"))
+			Expect(theme.Red).To(Equal("#cc6666"))
+			Expect(theme.BrightRed).To(Equal("#ff3334"))
+		})
+
+		It("should import Ghostty themes", func() {
+			theme, err := load("Tomorrow Night", `
+palette = 0=#1d1f21
+palette = 12=81a2be
+background = 1d1f21
+foreground = #c5c8c6
+cursor-color = #aeafad
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("Tomorrow Night"))
+			Expect(theme.Black).To(Equal("#1d1f21"))
+			Expect(theme.BrightBlue).To(Equal("#81a2be"))
+			Expect(theme.Background).To(Equal("#1d1f21"))
+		})
+
+		It("should import X resources", func() {
+			theme, err := load(".Xresources", `
+! Tomorrow Night
+#define t_red #cc6666
+*.background: #1d1f21
+URxvt*foreground: rgb:c5/c8/c6
+*color1: t_red
+*.color9: #f33
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Background).To(Equal("#1d1f21"))
+			Expect(theme.Foreground).To(Equal("#c5c8c6"))
+			Expect(theme.Red).To(Equal("#cc6666"))
+			Expect(theme.BrightRed).To(Equal("#ff3333"))
+		})
+
+		It("should fail for invalid colors and unknown formats", func() {
+			_, err := load("kitty.conf", "background not-a-color\n")
+			Expect(err).To(MatchError(ContainSubstring(`invalid color "not-a-color"`)))
+
+			_, err = load("unknown", "just some text\n")
+			Expect(err).To(MatchError(ContainSubstring("unknown format")))
+		})
+	})
+
+	Context("loading base16 and base24 schemes", func() {
+		const tomorrowNight = `
+base00: "1d1f21"
+base01: "282a2e"
+base02: "373b41"
+base03: "969896"
+base04: "b4b7b4"
+base05: "c5c8c6"
+base06: "e0e0e0"
+base07: "ffffff"
+base08: "cc6666"
+base09: "de935f"
+base0A: "f0c674"
+base0B: "b5bd68"
+base0C: "8abeb7"
+base0D: "81a2be"
+base0E: "b294bb"
+base0F: "a3685a"
+`
+
+		It("should derive a theme from a legacy base16 scheme", func() {
+			theme, err := load("tomorrow-night.yaml", "scheme: \"Tomorrow Night\"\nauthor: \"Chris Kempson\"\n"+tomorrowNight)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("Tomorrow Night"))
+			Expect(theme.Background).To(Equal("#1d1f21"))
+			Expect(theme.Foreground).To(Equal("#c5c8c6"))
+			Expect(theme.Black).To(Equal("#1d1f21"))
+			Expect(theme.Red).To(Equal("#cc6666"))
+			Expect(theme.Yellow).To(Equal("#f0c674"))
+			Expect(theme.White).To(Equal("#c5c8c6"))
+			Expect(theme.BrightBlack).To(Equal("#969896"))
+			Expect(theme.BrightRed).To(Equal("#cc6666"))
+			Expect(theme.BrightWhite).To(Equal("#ffffff"))
+			Expect(theme.WindowRed).To(Equal("#cc6666"))
+			Expect(theme.WindowBorder).To(Equal("#373b41"))
+			Expect(theme.Shadow).To(Equal("#1d1f2166"))
+		})
+
+		It("should derive a theme from a base24 scheme with a palette", func() {
+			theme, err := load("scheme.yml", `
+system: "base24"
+name: "Example"
+variant: "dark"
+palette:
+`+strings.ReplaceAll(strings.ReplaceAll(tomorrowNight, "\nbase", "\n  base"), `"`, `"#`)+`
+  base10: "#111111"
+  base11: "#000000"
+  base12: "#ff0000"
+  base13: "#ffff00"
+  base14: "#00ff00"
+  base15: "#00ffff"
+  base16: "#0000ff"
+  base17: "#ff00ff"
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("Example"))
+			Expect(theme.Red).To(Equal("#cc6666"))
+			Expect(theme.BrightRed).To(Equal("#ff0000"))
+			Expect(theme.BrightYellow).To(Equal("#ffff00"))
+			Expect(theme.BrightBlue).To(Equal("#0000ff"))
+			Expect(theme.BrightMagenta).To(Equal("#ff00ff"))
+			Expect(theme.BrightCyan).To(Equal("#00ffff"))
+			Expect(theme.Shadow).To(Equal("#00000066"))
+		})
+
+		It("should fail for schemes with missing colors", func() {
+			_, err := load("broken.yaml", strings.ReplaceAll(tomorrowNight, `base0C: "8abeb7"`, ""))
+			Expect(err).To(MatchError(ContainSubstring("missing color base0C")))
+		})
+	})
+})
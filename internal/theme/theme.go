@@ -1,11 +1,14 @@
 package theme
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"image/color"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
 // Theme defines the color scheme for the terminal screenshot
@@ -40,6 +43,13 @@ type Theme struct {
 	BrightMagenta string `json:"bright_magenta"`
 	BrightCyan   string `json:"bright_cyan"`
 	BrightWhite  string `json:"bright_white"`
+
+	// Colors of the 256 color palette by index, which take precedence over
+	// the ANSI colors above, undefined colors use the typical xterm values
+	Palette map[int]string `json:"palette,omitempty"`
+
+	// Standard error, in case it is captured separately (defaults to red)
+	Stderr string `json:"stderr,omitempty"`
 }
 
 // Preset themes
@@ -262,21 +272,89 @@ func GetTheme(name string) Theme {
 	return themes["default"]
 }
 
-// LoadThemeFromFile loads a theme from a JSON file
+// LoadThemeFromFile loads a theme from a termshot JSON file, or imports the
+// color scheme of an iTerm2, Windows Terminal, Alacritty, kitty, Ghostty,
+// Xresources, or base16/base24 file, the format is detected based on the
+// extension and content
 func LoadThemeFromFile(path string) (Theme, error) {
+	return loadTheme(path, nil)
+}
+
+// loadTheme loads a theme file, the chain are the theme files that extend
+// it, which must not be extended by the theme file again
+func loadTheme(path string, chain []string) (Theme, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		if slices.Contains(chain, abs) {
+			return Theme{}, fmt.Errorf("cycle of themes extending each other: %s", strings.Join(append(chain, abs), " → "))
+		}
+
+		chain = append(chain, abs)
+	}
+
 	data, err := os.ReadFile(filepath.Clean(path))
 	if err != nil {
 		return Theme{}, fmt.Errorf("failed to read theme file: %w", err)
 	}
 
-	var theme Theme
-	if err := json.Unmarshal(data, &theme); err != nil {
+	theme, err := parseTheme(path, data, chain)
+	if err != nil {
 		return Theme{}, fmt.Errorf("failed to parse theme file: %w", err)
 	}
 
 	return theme, nil
 }
 
+// Color returns the color of the 256 color palette with the given index,
+// which is either defined by the palette of the theme or one of the ANSI
+// colors, an empty string means the theme does not define the color
+func (t Theme) Color(index int) string {
+	if hex, ok := t.Palette[index]; ok {
+		return hex
+	}
+
+	var ansi = [16]string{
+		t.Black, t.Red, t.Green, t.Yellow, t.Blue, t.Magenta, t.Cyan, t.White,
+		t.BrightBlack, t.BrightRed, t.BrightGreen, t.BrightYellow,
+		t.BrightBlue, t.BrightMagenta, t.BrightCyan, t.BrightWhite,
+	}
+
+	if index >= 0 && index < len(ansi) {
+		return ansi[index]
+	}
+
+	return ""
+}
+
+// extendedTheme returns the theme that a theme file extends, which is either
+// a preset theme, or another theme file relative to the extending one
+func extendedTheme(name string, path string, chain []string) (Theme, error) {
+	if preset, ok := themes[name]; ok {
+		return preset, nil
+	}
+
+	file := name
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(path), file)
+	}
+
+	if _, err := os.Stat(file); err != nil {
+		return Theme{}, fmt.Errorf("neither a preset theme (%s) nor a theme file", strings.Join(slices.Sorted(maps.Keys(themes)), ", "))
+	}
+
+	theme, err := loadTheme(file, chain)
+	if err != nil {
+		// the problems of the extended theme file are reported as they are,
+		// without the context that the theme file failed to parse
+		if unwrapped := errors.Unwrap(err); unwrapped != nil {
+			return Theme{}, unwrapped
+		}
+
+		return Theme{}, err
+	}
+
+	return theme, nil
+}
+
 // ListThemes returns a list of all available preset themes
 func ListThemes() []string {
 	var names []string
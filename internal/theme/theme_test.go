@@ -0,0 +1,93 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package theme_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/theme"
+)
+
+var _ = Describe("Themes", func() {
+	Context("looking up colors of the 256 color palette", func() {
+		It("should use the ANSI colors and the palette of the theme", func() {
+			theme := GetTheme("dracula")
+			theme.Palette = map[int]string{1: "#aa0000", 232: "#080808"}
+
+			Expect(theme.Color(0)).To(Equal("#21222c"))
+			Expect(theme.Color(1)).To(Equal("#aa0000"))
+			Expect(theme.Color(15)).To(Equal("#ffffff"))
+			Expect(theme.Color(232)).To(Equal("#080808"))
+			Expect(theme.Color(233)).To(BeEmpty())
+		})
+	})
+
+	Context("extending other themes", func() {
+		var dir string
+		var write = func(name string, content string) string {
+			path := filepath.Join(dir, name)
+			Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			return path
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+		})
+
+		It("should only override the colors that are set", func() {
+			theme, err := LoadThemeFromFile(write("brand.json", `{"name": "Brand", "extends": "dracula", "window_red": "#123456", "palette": {"208": "#ff8700"}}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			expected := GetTheme("dracula")
+			expected.Name, expected.WindowRed = "Brand", "#123456"
+			expected.Palette = map[int]string{208: "#ff8700"}
+			Expect(theme).To(Equal(expected))
+		})
+
+		It("should extend theme files relative to the extending one", func() {
+			write("brand.json", `{"name": "Brand", "extends": "dracula", "window_red": "#123456", "palette": {"208": "#ff8700", "209": "#ff875f"}}`)
+			Expect(os.Mkdir(filepath.Join(dir, "light"), 0755)).To(Succeed())
+
+			theme, err := LoadThemeFromFile(write(filepath.Join("light", "brand-light.json"), `{"extends": "../brand.json", "background": "#f8f8f2", "palette": {"209": "#000000"}}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("brand-light"))
+			Expect(theme.Background).To(Equal("#f8f8f2"))
+			Expect(theme.WindowRed).To(Equal("#123456"))
+			Expect(theme.Red).To(Equal(GetTheme("dracula").Red))
+			Expect(theme.Palette).To(Equal(map[int]string{208: "#ff8700", 209: "#000000"}))
+		})
+
+		It("should fail for unknown themes and cycles", func() {
+			_, err := LoadThemeFromFile(write("unknown.json", `{"extends": "nope"}`))
+			Expect(err).To(MatchError(ContainSubstring(`line 1, column 13: failed to extend "nope": neither a preset theme`)))
+
+			write("a.json", `{"extends": "b.json"}`)
+			_, err = LoadThemeFromFile(write("b.json", `{"extends": "a.json"}`))
+			Expect(err).To(MatchError(ContainSubstring("cycle of themes extending each other: " +
+				strings.Join([]string{filepath.Join(dir, "b.json"), filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}, " → "))))
+		})
+	})
+})
@@ -0,0 +1,176 @@
+package theme
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML that is used by color schemes: tables,
+// dotted keys, strings, and inline tables, all other values like numbers or
+// arrays are kept as their text
+func parseTOML(data []byte) (map[string]any, error) {
+	var doc = map[string]any{}
+	var current = doc
+
+	var scanner = bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "[["):
+			// arrays of tables are not used for colors, their keys are
+			// read into a table that is not part of the document
+			current = map[string]any{}
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header %q", number, line)
+			}
+
+			table, err := tomlTable(doc, tomlKey(line[1:len(line)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+
+			current = table
+
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected key = value, got %q", number, line)
+			}
+
+			// values like arrays can span multiple lines
+			for strings.Count(value, "[") > strings.Count(value, "]") && scanner.Scan() {
+				number++
+				value += " " + strings.TrimSpace(stripTOMLComment(scanner.Text()))
+			}
+
+			parsed, err := tomlValue(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+
+			if err := tomlSet(current, tomlKey(key), parsed); err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+		}
+	}
+
+	return doc, scanner.Err()
+}
+
+// stripTOMLComment removes a comment, which starts with a # outside of
+// quoted strings
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+
+		case quote != 0 && r == quote && (quote == '\'' || i == 0 || line[i-1] != '\\'):
+			quote = 0
+
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// tomlKey splits a dotted key into its parts, quoted parts are unquoted
+func tomlKey(key string) []string {
+	var parts = strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+
+	return parts
+}
+
+// tomlTable returns the table with the given key, missing tables are created
+func tomlTable(doc map[string]any, key []string) (map[string]any, error) {
+	var current = doc
+	for _, part := range key {
+		switch next := current[part].(type) {
+		case map[string]any:
+			current = next
+
+		case nil:
+			table := map[string]any{}
+			current[part] = table
+			current = table
+
+		default:
+			return nil, fmt.Errorf("key %q is not a table", strings.Join(key, "."))
+		}
+	}
+
+	return current, nil
+}
+
+// tomlSet sets the value of a dotted key in the table
+func tomlSet(table map[string]any, key []string, value any) error {
+	parent, err := tomlTable(table, key[:len(key)-1])
+	if err != nil {
+		return err
+	}
+
+	parent[key[len(key)-1]] = value
+	return nil
+}
+
+// tomlValue parses a string or an inline table, other values are returned
+// as their text
+func tomlValue(value string) (any, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		return strconv.Unquote(value)
+
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return nil, fmt.Errorf("invalid string %s", value)
+		}
+
+		return value[1 : len(value)-1], nil
+
+	case strings.HasPrefix(value, "{"):
+		if !strings.HasSuffix(value, "}") {
+			return nil, fmt.Errorf("invalid inline table %s", value)
+		}
+
+		var table = map[string]any{}
+		for _, entry := range strings.Split(value[1:len(value)-1], ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+
+			key, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid inline table entry %q", entry)
+			}
+
+			parsed, err := tomlValue(strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+
+			if err := tomlSet(table, tomlKey(key), parsed); err != nil {
+				return nil, err
+			}
+		}
+
+		return table, nil
+
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,342 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// requiredColors are the fields of a termshot JSON theme that must be set
+var requiredColors = []string{
+	"background", "foreground",
+	"window_red", "window_yellow", "window_green", "window_border", "shadow",
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright_black", "bright_red", "bright_green", "bright_yellow",
+	"bright_blue", "bright_magenta", "bright_cyan", "bright_white",
+}
+
+// ValidationError is a problem of a theme file, the line and column are
+// zero in case the problem does not relate to a position in the file
+type ValidationError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidationErrors are all problems found in a theme file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	var messages = make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// colors returns the color fields of the theme by their JSON name
+func (t *Theme) colors() map[string]*string {
+	return map[string]*string{
+		"background":     &t.Background,
+		"foreground":     &t.Foreground,
+		"window_red":     &t.WindowRed,
+		"window_yellow":  &t.WindowYellow,
+		"window_green":   &t.WindowGreen,
+		"window_border":  &t.WindowBorder,
+		"shadow":         &t.Shadow,
+		"black":          &t.Black,
+		"red":            &t.Red,
+		"green":          &t.Green,
+		"yellow":         &t.Yellow,
+		"blue":           &t.Blue,
+		"magenta":        &t.Magenta,
+		"cyan":           &t.Cyan,
+		"white":          &t.White,
+		"bright_black":   &t.BrightBlack,
+		"bright_red":     &t.BrightRed,
+		"bright_green":   &t.BrightGreen,
+		"bright_yellow":  &t.BrightYellow,
+		"bright_blue":    &t.BrightBlue,
+		"bright_magenta": &t.BrightMagenta,
+		"bright_cyan":    &t.BrightCyan,
+		"bright_white":   &t.BrightWhite,
+		"stderr":         &t.Stderr,
+	}
+}
+
+// jsonTheme parses a termshot JSON theme, syntax errors, unknown fields, and
+// invalid or missing colors are all reported with their position. A theme
+// that extends another theme only needs the colors it changes, the chain
+// are the theme files that extend this one.
+func jsonTheme(path string, data []byte, chain []string) (Theme, error) {
+	var theme Theme
+	var v = validator{data: data}
+	var colors = theme.colors()
+	var seen = map[string]bool{}
+
+	var extends string
+	var extendsOffset int64
+
+	complete := v.object(data, 0, func(key string, keyOffset int64, value json.RawMessage, valueOffset int64) {
+		seen[key] = true
+		switch {
+		case key == "name":
+			if err := json.Unmarshal(value, &theme.Name); err != nil {
+				v.report(valueOffset, "field %q must be a string", key)
+			}
+
+		case key == "extends":
+			if err := json.Unmarshal(value, &extends); err != nil || extends == "" {
+				v.report(valueOffset, "field %q must be the name of a preset theme or a theme file", key)
+			}
+
+			extendsOffset = valueOffset
+
+		case key == "palette":
+			theme.Palette = map[int]string{}
+			v.object(value, valueOffset, func(key string, keyOffset int64, value json.RawMessage, valueOffset int64) {
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index > 255 {
+					v.report(keyOffset, "invalid palette index %q, indexes are numbers from 0 to 255", key)
+					return
+				}
+
+				if hex, ok := v.color(value, valueOffset, "palette entry "+strconv.Quote(key)); ok {
+					theme.Palette[index] = hex
+				}
+			})
+
+		case colors[key] != nil:
+			if hex, ok := v.color(value, valueOffset, "field "+strconv.Quote(key)); ok {
+				*colors[key] = hex
+			}
+
+		default:
+			v.report(keyOffset, "unknown field %q%s", key, suggestion(key, colors))
+		}
+	})
+
+	// colors that are not set are taken from the extended theme
+	if complete && extends != "" {
+		base, err := extendedTheme(extends, path, chain)
+		if err != nil {
+			v.report(extendsOffset, "failed to extend %q: %v", extends, err)
+		}
+
+		for key, color := range base.colors() {
+			if !seen[key] {
+				*colors[key] = *color
+			}
+		}
+
+		var palette = maps.Clone(base.Palette)
+		for index, hex := range theme.Palette {
+			if palette == nil {
+				palette = map[int]string{}
+			}
+
+			palette[index] = hex
+		}
+
+		theme.Palette = palette
+	}
+
+	// missing colors are only meaningful if the whole file could be read
+	var missing []string
+	for _, key := range requiredColors {
+		if complete && !seen["extends"] && !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		v.errs = append(v.errs, ValidationError{Message: "missing colors: " + strings.Join(missing, ", ")})
+	}
+
+	if len(v.errs) > 0 {
+		return Theme{}, v.errs
+	}
+
+	return theme, nil
+}
+
+// validator collects the problems found in a JSON document
+type validator struct {
+	data []byte
+	errs ValidationErrors
+}
+
+// report adds a problem at the given offset of the document
+func (v *validator) report(offset int64, format string, a ...any) {
+	var line, column = 1, 1
+	for _, b := range v.data[:min(max(offset, 0), int64(len(v.data)))] {
+		switch b {
+		case '\n':
+			line, column = line+1, 1
+
+		default:
+			// columns count characters, not the bytes of UTF-8 sequences
+			if b&0xC0 != 0x80 {
+				column++
+			}
+		}
+	}
+
+	v.errs = append(v.errs, ValidationError{Line: line, Column: column, Message: fmt.Sprintf(format, a...)})
+}
+
+// color checks that the value is a string with a hex color
+func (v *validator) color(value json.RawMessage, offset int64, name string) (string, bool) {
+	var hex string
+	if err := json.Unmarshal(value, &hex); err != nil {
+		v.report(offset, "%s must be a string", name)
+		return "", false
+	}
+
+	if _, err := ParseColor(hex); err != nil {
+		v.report(offset, "invalid color %q in %s, colors are hex colors like #rrggbb or #rrggbbaa", hex, name)
+		return "", false
+	}
+
+	return hex, true
+}
+
+// object calls the function for each field of the JSON object, which is at
+// the given offset of the document, and returns whether it could be read
+func (v *validator) object(value []byte, offset int64, fn func(key string, keyOffset int64, value json.RawMessage, valueOffset int64)) bool {
+	var dec = json.NewDecoder(bytes.NewReader(value))
+
+	// skip returns the offset of the next token in the document, which
+	// follows the decoder position after whitespace and separators
+	var skip = func() int64 {
+		var i = dec.InputOffset()
+		for i < int64(len(value)) && strings.IndexByte(" \t\r\n,:", value[i]) >= 0 {
+			i++
+		}
+
+		return offset + i
+	}
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		if err != nil {
+			return v.syntax(err, offset, value)
+		}
+
+		v.report(offset, "expected an object")
+		return false
+	}
+
+	for dec.More() {
+		keyOffset := skip()
+		tok, err := dec.Token()
+		if err != nil {
+			return v.syntax(err, offset, value)
+		}
+
+		valueOffset := skip()
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return v.syntax(err, offset, value)
+		}
+
+		fn(tok.(string), keyOffset, raw, valueOffset)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return v.syntax(err, offset, value)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		v.report(skip(), "unexpected content after the object")
+		return false
+	}
+
+	return true
+}
+
+// syntax reports a syntax error of the JSON value at the given offset
+func (v *validator) syntax(err error, offset int64, value []byte) bool {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(value)):
+		v.report(offset+int64(len(value)), "unexpected end of file")
+
+	case errors.As(err, &syntaxErr):
+		// the offset of a syntax error is right after the invalid character
+		v.report(offset+max(syntaxErr.Offset-1, 0), "%s", strings.TrimPrefix(syntaxErr.Error(), "json: "))
+
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		v.report(offset+int64(len(value)), "unexpected end of file")
+
+	default:
+		v.report(offset, "%v", err)
+	}
+
+	return false
+}
+
+// suggestion returns a hint for unknown fields, which are likely typos of
+// one of the known fields
+func suggestion(key string, colors map[string]*string) string {
+	var best string
+	var distance = 3
+	for _, known := range append([]string{"name", "extends", "palette"}, keysOf(colors)...) {
+		if d := levenshtein(key, known); d < distance || (d == distance && known < best) {
+			best, distance = known, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+func keysOf(m map[string]*string) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// levenshtein returns the edit distance of two strings
+func levenshtein(a string, b string) int {
+	var previous = make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		var current = make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
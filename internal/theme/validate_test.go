@@ -0,0 +1,89 @@
+// Copyright © 2026 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package theme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/termshot/internal/theme"
+)
+
+// complete returns a termshot JSON theme with all colors of the preset,
+// in which the given text replaces the name field
+func complete(preset string, replacement string) string {
+	data, err := json.MarshalIndent(GetTheme(preset), "", "  ")
+	Expect(err).ToNot(HaveOccurred())
+
+	lines := strings.Split(string(data), "\n")
+	Expect(lines[1]).To(HavePrefix(`  "name"`))
+	lines[1] = replacement
+	return strings.Join(lines, "\n")
+}
+
+var _ = Describe("Validating themes", func() {
+	Context("loading termshot JSON themes", func() {
+		It("should use the file name in case the theme has no name", func() {
+			theme, err := load("my-theme.json", complete("nord", `  "shadow": "#00000066",`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("my-theme"))
+		})
+
+		It("should report unknown fields with their position and a suggestion", func() {
+			_, err := load("theme.json", complete("nord", `  "backgrund": "#000000", "extra": 1,`))
+			Expect(err).To(MatchError(ContainSubstring(`line 2, column 3: unknown field "backgrund", did you mean "background"?`)))
+			Expect(err).To(MatchError(HaveSuffix(`line 2, column 27: unknown field "extra"`)))
+		})
+
+		It("should report invalid colors with their position", func() {
+			_, err := load("theme.json", complete("nord", `  "red": "#ff00", "green": 42, "palette": {"16": "#000000", "256": "#000000", "17": "blue"},`))
+			Expect(err).To(HaveOccurred())
+
+			var errs ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(errs).To(Equal(ValidationErrors{
+				{Line: 2, Column: 10, Message: `invalid color "#ff00" in field "red", colors are hex colors like #rrggbb or #rrggbbaa`},
+				{Line: 2, Column: 28, Message: `field "green" must be a string`},
+				{Line: 2, Column: 61, Message: `invalid palette index "256", indexes are numbers from 0 to 255`},
+				{Line: 2, Column: 85, Message: `invalid color "blue" in palette entry "17", colors are hex colors like #rrggbb or #rrggbbaa`},
+			}))
+		})
+
+		It("should report missing colors", func() {
+			_, err := load("theme.json", `{"name": "Partial", "background": "#000000"}`)
+			Expect(err).To(MatchError(HaveSuffix("missing colors: foreground, window_red, window_yellow, window_green, window_border, shadow, " +
+				"black, red, green, yellow, blue, magenta, cyan, white, " +
+				"bright_black, bright_red, bright_green, bright_yellow, bright_blue, bright_magenta, bright_cyan, bright_white")))
+		})
+
+		It("should report syntax errors with their position", func() {
+			_, err := load("theme.json", "{\n  \"name\": \"Broken\",\n  \"background\" \"#000000\"\n}")
+			Expect(err).To(MatchError(ContainSubstring(`line 3, column 16: invalid character '"' after object key`)))
+
+			_, err = load("theme.json", "{\n  \"name\": \"Broken\",\n")
+			Expect(err).To(MatchError(ContainSubstring(`line 3, column 1: unexpected end of file`)))
+		})
+	})
+})